@@ -3,8 +3,14 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"whisper-transcriber/pkg/models"
+	"whisper-transcriber/internal/infrastructure"
 	"whisper-transcriber/internal/service"
 
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
@@ -17,9 +23,25 @@ type App struct {
 	ffmpeg         models.FFmpegService
 	formatter      models.Formatter
 	queue          models.FileQueue
+	cache          models.ResultCache
+	library        models.TranscriptLibrary
+	editor         *service.Editor
+	evaluator      *service.Evaluator
+	benchmarker    *service.Benchmarker
 	batch          *service.BatchProcessor
+	watcher        *service.Watcher
 	batchCancel    context.CancelFunc
+	evalCancel     context.CancelFunc
+	benchCancel    context.CancelFunc
 	downloadCancel context.CancelFunc
+
+	mu           sync.Mutex
+	batchRunning bool
+	rerunConfig  *models.TranscriptionConfig
+	hashCancels  map[int]context.CancelFunc
+	hashSeq      int
+	watchConfig  models.WatchConfig
+	watchSources map[string]string
 }
 
 func NewApp(
@@ -28,7 +50,13 @@ func NewApp(
 	ffmpeg models.FFmpegService,
 	formatter models.Formatter,
 	queue models.FileQueue,
+	cache models.ResultCache,
+	library models.TranscriptLibrary,
+	editor *service.Editor,
+	evaluator *service.Evaluator,
+	benchmarker *service.Benchmarker,
 	batch *service.BatchProcessor,
+	watcher *service.Watcher,
 ) *App {
 	return &App{
 		transcriber:  transcriber,
@@ -36,15 +64,34 @@ func NewApp(
 		ffmpeg:       ffmpeg,
 		formatter:    formatter,
 		queue:        queue,
+		cache:        cache,
+		library:      library,
+		editor:       editor,
+		evaluator:    evaluator,
+		benchmarker:  benchmarker,
 		batch:        batch,
+		watcher:      watcher,
+		hashCancels:  make(map[int]context.CancelFunc),
+		watchSources: make(map[string]string),
 	}
 }
 
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.batch.UpdateEstimates(a.modelManager.ModelPath())
+
+	// Files added just before the last exit still need their hash.
+	var ids []string
+	for _, f := range a.queue.Snapshot() {
+		if f.Status == "hashing" {
+			ids = append(ids, f.ID)
+		}
+	}
+	a.hashFiles(ids, nil)
 }
 
 func (a *App) shutdown(_ context.Context) {
+	a.watcher.Stop()
 	a.transcriber.Close()
 }
 
@@ -52,8 +99,8 @@ func (a *App) BrowseFiles() ([]models.FileItem, error) {
 	selection, err := wailsRuntime.OpenMultipleFilesDialog(a.ctx, wailsRuntime.OpenDialogOptions{
 		Title: "Select Video Files",
 		Filters: []wailsRuntime.FileFilter{
-			{DisplayName: "Video Files", Pattern: "*.mp4;*.mkv;*.avi;*.mov;*.webm"},
-			{DisplayName: "Audio Files", Pattern: "*.wav;*.mp3;*.flac;*.ogg;*.m4a"},
+			{DisplayName: "Video Files", Pattern: extPattern(service.VideoExtensions)},
+			{DisplayName: "Audio Files", Pattern: extPattern(service.AudioExtensions)},
 			{DisplayName: "All Files", Pattern: "*.*"},
 		},
 	})
@@ -61,11 +108,126 @@ func (a *App) BrowseFiles() ([]models.FileItem, error) {
 		return nil, err
 	}
 
-	return a.queue.Add(selection), nil
+	return a.addFiles(selection), nil
+}
+
+func (a *App) BrowseFolder(opts models.ScanOptions) ([]models.FileItem, error) {
+	dir, err := wailsRuntime.OpenDirectoryDialog(a.ctx, wailsRuntime.OpenDialogOptions{
+		Title: "Select Folder",
+	})
+	if err != nil || dir == "" {
+		return nil, err
+	}
+	return a.AddFolder(dir, opts)
+}
+
+func (a *App) AddFolder(dir string, opts models.ScanOptions) ([]models.FileItem, error) {
+	paths, err := service.ScanFolder(a.ctx, dir, opts)
+	if err != nil {
+		return nil, fmt.Errorf("scan %s: %w", dir, err)
+	}
+	return a.addFiles(paths), nil
 }
 
+// AddFiles enqueues dropped paths; folders are scanned recursively with the
+// default filters.
 func (a *App) AddFiles(paths []string) ([]models.FileItem, error) {
-	return a.queue.Add(paths), nil
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil || !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		found, err := service.ScanFolder(a.ctx, p, models.ScanOptions{Recursive: true})
+		if err != nil {
+			return nil, fmt.Errorf("scan %s: %w", p, err)
+		}
+		files = append(files, found...)
+	}
+	return a.addFiles(files), nil
+}
+
+// addFiles enqueues paths and hashes them in the background, so large files
+// do not block the call.
+func (a *App) addFiles(paths []string) []models.FileItem {
+	items := a.queue.Add(a.ctx, paths)
+	var ids []string
+	for _, item := range items {
+		if item.Status == "hashing" {
+			ids = append(ids, item.ID)
+		}
+	}
+	a.hashFiles(ids, nil)
+	return items
+}
+
+// hashFiles hashes ids on a context of their own, which CancelHashing ends.
+// Files still unhashed then are removed from the queue; otherwise then, if
+// set, runs once all are done.
+func (a *App) hashFiles(ids []string, then func()) {
+	if len(ids) == 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.mu.Lock()
+	a.hashSeq++
+	seq := a.hashSeq
+	a.hashCancels[seq] = cancel
+	a.mu.Unlock()
+
+	go func() {
+		defer func() {
+			a.mu.Lock()
+			delete(a.hashCancels, seq)
+			a.mu.Unlock()
+			cancel()
+		}()
+		onStatus := fileStatusCb(a.ctx)
+		removed := a.queue.Hash(ctx, ids, func(id, status string, progress int, err error) {
+			onStatus(id, status, progress, err, models.FileTiming{})
+		})
+		if len(removed) > 0 {
+			wailsRuntime.EventsEmit(a.ctx, "file:removed", removed)
+		}
+		if then != nil && ctx.Err() == nil {
+			then()
+		}
+	}()
+}
+
+// CancelHashing stops hashing files that are still being added and removes
+// them from the queue.
+func (a *App) CancelHashing() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, cancel := range a.hashCancels {
+		cancel()
+	}
+}
+
+func extPattern(exts []string) string {
+	patterns := make([]string, len(exts))
+	for i, ext := range exts {
+		patterns[i] = "*" + ext
+	}
+	return strings.Join(patterns, ";")
+}
+
+func (a *App) GetFiles() []models.FileItem {
+	return a.queue.Snapshot()
+}
+
+func (a *App) MoveFile(id string, index int) error {
+	return a.queue.Move(id, index)
+}
+
+func (a *App) ReorderFiles(ids []string) error {
+	return a.queue.Reorder(ids)
+}
+
+func (a *App) SetFilePriority(id string, priority int) error {
+	return a.queue.SetPriority(id, priority)
 }
 
 func (a *App) ClearFiles() {
@@ -76,6 +238,27 @@ func (a *App) RemoveFile(id string) {
 	a.queue.Remove(id)
 }
 
+func (a *App) GetAudioTracks(id string) ([]models.AudioStream, error) {
+	for _, f := range a.queue.Snapshot() {
+		if f.ID != id {
+			continue
+		}
+		if f.Media == nil {
+			return nil, nil
+		}
+		return f.Media.AudioStreams, nil
+	}
+	return nil, fmt.Errorf("file not found: %s", id)
+}
+
+func (a *App) SetAudioTracks(id string, streamIndexes []int) error {
+	return a.queue.SetAudioTracks(id, streamIndexes)
+}
+
+func (a *App) SetTimeRanges(id string, ranges []models.TimeRange) error {
+	return a.queue.SetRanges(id, ranges)
+}
+
 func (a *App) GetLanguages() []models.LangOption {
 	return []models.LangOption{
 		{Code: "auto", Name: "Auto-detect"},
@@ -97,6 +280,10 @@ func (a *App) GetLanguages() []models.LangOption {
 	}
 }
 
+func (a *App) GetPreprocessPresets() []string {
+	return service.PreprocessPresets()
+}
+
 func (a *App) IsFFmpegAvailable() bool {
 	return a.ffmpeg.IsAvailable()
 }
@@ -107,9 +294,11 @@ func (a *App) DownloadFFmpeg() {
 	go func() {
 		defer func() { a.downloadCancel = nil }()
 		if err := a.ffmpeg.Download(ctx, downloadProgressCb(a.ctx, "ffmpeg:download:progress")); err != nil {
-			wailsRuntime.EventsEmit(a.ctx, "ffmpeg:download:error", err.Error())
+			slog.Error("ffmpeg download failed", "err", err)
+			emitError(a.ctx, "ffmpeg:download:error", err)
 			return
 		}
+		slog.Info("ffmpeg downloaded")
 		wailsRuntime.EventsEmit(a.ctx, "ffmpeg:download:done", nil)
 	}()
 }
@@ -124,9 +313,11 @@ func (a *App) DownloadModel() {
 	go func() {
 		defer func() { a.downloadCancel = nil }()
 		if err := a.modelManager.DownloadModel(ctx, downloadProgressCb(a.ctx, "model:download:progress")); err != nil {
-			wailsRuntime.EventsEmit(a.ctx, "model:download:error", err.Error())
+			slog.Error("model download failed", "err", err)
+			emitError(a.ctx, "model:download:error", err)
 			return
 		}
+		slog.Info("model downloaded", "path", a.modelManager.ModelPath())
 		wailsRuntime.EventsEmit(a.ctx, "model:download:done", a.modelManager.ModelPath())
 	}()
 }
@@ -138,45 +329,428 @@ func (a *App) CancelDownload() {
 }
 
 func (a *App) StartTranscription(config models.TranscriptionConfig) error {
-	if !a.modelManager.IsModelAvailable() {
-		return fmt.Errorf("model not found — download it first")
+	return a.runBatch(config)
+}
+
+func (a *App) RetryFailed(config models.TranscriptionConfig) error {
+	var ids []string
+	for _, f := range a.queue.Snapshot() {
+		if f.Status == "error" && a.queue.Requeue(f.ID) == nil {
+			ids = append(ids, f.ID)
+		}
 	}
+	return a.retry(ids, config)
+}
 
-	if !a.ffmpeg.IsAvailable() {
-		return fmt.Errorf("FFmpeg not found — download it first")
+func (a *App) RetryFile(id string, config models.TranscriptionConfig) error {
+	if err := a.queue.Requeue(id); err != nil {
+		return err
 	}
+	return a.retry([]string{id}, config)
+}
 
-	if !a.transcriber.IsLoaded() {
-		wailsRuntime.EventsEmit(a.ctx, "model:loading", nil)
-		if err := a.transcriber.LoadModel(a.modelManager.ModelPath()); err != nil {
-			return fmt.Errorf("failed to load model: %w", err)
+// retry makes sure requeued files get run: by the running batch if it is
+// still taking files, otherwise by a new batch, started once the running one
+// finishes.
+func (a *App) retry(ids []string, config models.TranscriptionConfig) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	a.mu.Lock()
+	if !a.batchRunning {
+		a.mu.Unlock()
+		return a.runBatch(config)
+	}
+	for _, id := range ids {
+		if !a.batch.Requeue(id) {
+			a.rerunConfig = &config
 		}
-		wailsRuntime.EventsEmit(a.ctx, "model:loaded", nil)
 	}
+	a.mu.Unlock()
+	return nil
+}
+
+// runOrJoinBatch starts a batch unless one is running, in which case the
+// running batch picks requeued items up from the live queue.
+func (a *App) runOrJoinBatch(config models.TranscriptionConfig) error {
+	if a.isBatchRunning() {
+		return nil
+	}
+	return a.runBatch(config)
+}
+
+func (a *App) isBatchRunning() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.batchRunning
+}
+
+// busyErr reports why a batch, evaluation or benchmark cannot start. The
+// caller must hold a.mu and claim its own flag before releasing it, so two
+// starts racing through the slow model load cannot both succeed.
+func (a *App) busyErr() error {
+	switch {
+	case a.batchRunning:
+		return fmt.Errorf("a batch is already running")
+	case a.evalCancel != nil:
+		return fmt.Errorf("an evaluation is running")
+	case a.benchCancel != nil:
+		return fmt.Errorf("a benchmark is running")
+	}
+	return nil
+}
+
+func (a *App) runBatch(config models.TranscriptionConfig) error {
+	a.mu.Lock()
+	if err := a.busyErr(); err != nil {
+		a.mu.Unlock()
+		return err
+	}
+	a.batchRunning = true
+	a.mu.Unlock()
+
+	if err := a.prepareTranscriber(config); err != nil {
+		a.mu.Lock()
+		a.batchRunning = false
+		a.mu.Unlock()
+		return err
+	}
+
+	a.batch.Resume()
 
 	batchCtx, cancel := context.WithCancel(a.ctx)
+	a.mu.Lock()
 	a.batchCancel = cancel
+	a.mu.Unlock()
 
 	go a.batch.Run(
 		batchCtx,
 		config,
 		fileStatusCb(a.ctx),
-		func(fileID, outputPath string) {
+		func(fileID, outputPath string, result *models.TranscriptionResult) {
 			wailsRuntime.EventsEmit(a.ctx, "transcription:complete", map[string]interface{}{
 				"fileID":     fileID,
 				"outputPath": outputPath,
 			})
+			if entry, err := a.library.Add(result, outputPath); err != nil {
+				slog.Warn("add to library failed", "file", fileID, "err", err)
+				emitError(a.ctx, "library:error", err)
+			} else {
+				wailsRuntime.EventsEmit(a.ctx, "library:added", entry)
+			}
+			a.archiveWatched(fileID)
 		},
 		func() {
+			a.mu.Lock()
+			a.batchRunning = false
+			rerun := a.rerunConfig
+			a.rerunConfig = nil
+			a.mu.Unlock()
+			cancel()
 			wailsRuntime.EventsEmit(a.ctx, "batch:complete", nil)
+			if rerun != nil {
+				if err := a.runBatch(*rerun); err != nil {
+					emitError(a.ctx, "batch:error", err)
+				}
+			}
+			a.resumeWatch()
 		},
 	)
 	return nil
 }
 
+func (a *App) prepareTranscriber(config models.TranscriptionConfig) error {
+	if !a.modelManager.IsModelAvailable() {
+		return models.NewError(models.CodeModelMissing, "model not found — download it first", nil)
+	}
+
+	if !a.ffmpeg.IsAvailable() {
+		return models.ErrFFmpegNotFound
+	}
+
+	if _, err := service.PreprocessFilters(config.Preprocess); err != nil {
+		return err
+	}
+
+	if !service.ValidCleanupAction(config.Cleanup) {
+		return fmt.Errorf("unknown cleanup action: %s", config.Cleanup)
+	}
+
+	if !a.transcriber.IsLoaded() {
+		wailsRuntime.EventsEmit(a.ctx, "model:loading", nil)
+		if err := a.transcriber.LoadModel(a.modelManager.ModelPath()); err != nil {
+			return fmt.Errorf("failed to load model: %w", err)
+		}
+		wailsRuntime.EventsEmit(a.ctx, "model:loaded", nil)
+	}
+
+	if err := a.transcriber.SetConcurrency(config.Concurrency.Workers); err != nil {
+		return fmt.Errorf("failed to prepare workers: %w", err)
+	}
+	return nil
+}
+
+func (a *App) PauseTranscription(immediate bool) {
+	a.batch.Pause(immediate)
+	wailsRuntime.EventsEmit(a.ctx, "batch:paused", immediate)
+}
+
+func (a *App) ResumeTranscription() {
+	a.batch.Resume()
+	wailsRuntime.EventsEmit(a.ctx, "batch:resumed", nil)
+}
+
+func (a *App) IsTranscriptionPaused() bool {
+	return a.batch.IsPaused()
+}
+
+func (a *App) CancelFile(id string) error {
+	if a.batch.CancelFile(id) {
+		return nil
+	}
+	for _, f := range a.queue.Snapshot() {
+		if f.ID != id {
+			continue
+		}
+		if f.Status != "pending" && f.Status != "interrupted" {
+			return fmt.Errorf("file is not queued: %s", f.Name)
+		}
+		a.queue.UpdateStatus(id, "cancelled", 0, nil)
+		fileStatusCb(a.ctx)(id, "cancelled", 0, nil, models.FileTiming{})
+		return nil
+	}
+	return fmt.Errorf("file not found: %s", id)
+}
+
 func (a *App) CancelTranscription() {
-	if a.batchCancel != nil {
-		a.batchCancel()
+	a.mu.Lock()
+	cancel := a.batchCancel
+	a.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (a *App) ClearResultCache() error {
+	return a.cache.Clear()
+}
+
+// RunEvaluation transcribes a dataset and scores it against its reference
+// transcripts, emitting eval:progress and then eval:done with the report.
+func (a *App) RunEvaluation(cfg models.EvalConfig) error {
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.mu.Lock()
+	if err := a.busyErr(); err != nil {
+		a.mu.Unlock()
+		cancel()
+		return err
+	}
+	a.evalCancel = cancel
+	a.mu.Unlock()
+
+	cfg.Transcription.Concurrency.Workers = 1
+	if err := a.prepareTranscriber(cfg.Transcription); err != nil {
+		a.mu.Lock()
+		a.evalCancel = nil
+		a.mu.Unlock()
+		cancel()
+		return err
+	}
+
+	go func() {
+		defer func() {
+			a.mu.Lock()
+			a.evalCancel = nil
+			a.mu.Unlock()
+			cancel()
+		}()
+		report, err := a.evaluator.Run(ctx, cfg, func(done, total int, path string) {
+			wailsRuntime.EventsEmit(a.ctx, "eval:progress", map[string]interface{}{
+				"done":  done,
+				"total": total,
+				"path":  path,
+			})
+		})
+		if err != nil {
+			slog.Error("evaluation failed", "err", err)
+			emitError(a.ctx, "eval:error", err)
+			if report == nil {
+				return
+			}
+		}
+		wailsRuntime.EventsEmit(a.ctx, "eval:done", report)
+	}()
+	return nil
+}
+
+func (a *App) CancelEvaluation() {
+	a.mu.Lock()
+	cancel := a.evalCancel
+	a.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// RunBenchmark times the installed models, or cfg.Models, on a sample,
+// emitting bench:progress and then bench:done with the report.
+func (a *App) RunBenchmark(cfg models.BenchmarkConfig) error {
+	if !a.ffmpeg.IsAvailable() {
+		return fmt.Errorf("FFmpeg not found — download it first")
+	}
+	if len(cfg.Models) == 0 {
+		cfg.Models = a.modelManager.InstalledModels()
+	}
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.mu.Lock()
+	if err := a.busyErr(); err != nil {
+		a.mu.Unlock()
+		cancel()
+		return err
+	}
+	a.benchCancel = cancel
+	a.mu.Unlock()
+
+	go func() {
+		defer func() {
+			a.mu.Lock()
+			a.benchCancel = nil
+			a.mu.Unlock()
+			cancel()
+		}()
+		report, err := a.benchmarker.Run(ctx, cfg, func(done, total int, model string, threads int) {
+			wailsRuntime.EventsEmit(a.ctx, "bench:progress", map[string]interface{}{
+				"done":    done,
+				"total":   total,
+				"model":   model,
+				"threads": threads,
+			})
+		})
+		if err != nil {
+			slog.Error("benchmark failed", "err", err)
+			emitError(a.ctx, "bench:error", err)
+			if report == nil {
+				return
+			}
+		}
+		wailsRuntime.EventsEmit(a.ctx, "bench:done", report)
+	}()
+	return nil
+}
+
+func (a *App) CancelBenchmark() {
+	a.mu.Lock()
+	cancel := a.benchCancel
+	a.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func (a *App) GetBenchmarkReports() []models.BenchmarkReport {
+	return a.benchmarker.Reports()
+}
+
+func (a *App) RecommendModel() (*models.ModelRecommendation, error) {
+	return a.benchmarker.Recommend()
+}
+
+// RenameSpeakers names the speakers of a library transcript and, if the
+// batch produced an output for it, exports the renamed transcript next to it
+// as an edited copy. The rename is kept even if the export fails.
+func (a *App) RenameSpeakers(id string, names map[string]string) (*models.TranscriptionResult, error) {
+	result, err := a.editor.RenameSpeakers(id, names)
+	if err != nil {
+		return nil, err
 	}
+	entry, err := a.library.Entry(id)
+	if err != nil || entry.OutputPath == "" {
+		return result, err
+	}
+	_, err = a.editor.Export(id, strings.TrimPrefix(filepath.Ext(entry.OutputPath), "."))
+	return result, err
+}
+
+func (a *App) ListTranscripts() []models.LibraryEntry {
+	return a.library.List()
+}
+
+func (a *App) SearchTranscripts(query string) ([]models.LibraryHit, error) {
+	return a.library.Search(query)
+}
+
+func (a *App) OpenTranscript(id string) (*models.TranscriptionResult, error) {
+	return a.library.Get(id)
+}
+
+// ImportTranscript parses an existing subtitle or transcript file into the
+// library, where it can be edited and exported like a fresh result.
+func (a *App) ImportTranscript(path string) (models.LibraryEntry, error) {
+	result, err := service.ParseTranscript(path)
+	if err != nil {
+		return models.LibraryEntry{}, err
+	}
+	return a.library.Add(result, "")
+}
+
+func (a *App) ConvertSubtitle(inputPath string, opts models.ConvertOptions) (string, error) {
+	return service.Convert(a.formatter, inputPath, opts)
+}
+
+func (a *App) DeleteTranscript(id string) error {
+	return a.library.Delete(id)
+}
+
+func (a *App) EditSegmentText(id string, index int, text string) (*models.TranscriptionResult, error) {
+	return a.editor.EditText(id, index, text)
+}
+
+func (a *App) SplitSegment(id string, index, offset int, at float64) (*models.TranscriptionResult, error) {
+	return a.editor.SplitSegment(id, index, offset, at)
+}
+
+func (a *App) MergeSegments(id string, from, to int) (*models.TranscriptionResult, error) {
+	return a.editor.MergeSegments(id, from, to)
+}
+
+func (a *App) ShiftSegments(id string, from, to int, delta float64) (*models.TranscriptionResult, error) {
+	return a.editor.ShiftSegments(id, from, to, delta)
+}
+
+func (a *App) UndoEdit(id string) (*models.TranscriptionResult, error) {
+	return a.editor.Undo(id)
 }
 
+func (a *App) RedoEdit(id string) (*models.TranscriptionResult, error) {
+	return a.editor.Redo(id)
+}
+
+func (a *App) ExportTranscript(id, format string) (string, error) {
+	return a.editor.Export(id, format)
+}
+
+// OpenLogs shows the log folder in the system file manager.
+func (a *App) OpenLogs() error {
+	return infrastructure.OpenPath(infrastructure.LogDir())
+}
+
+// ExportLogs zips the log files to a location the user picks, for attaching
+// to bug reports. It returns the written path, or "" if the dialog was
+// cancelled.
+func (a *App) ExportLogs() (string, error) {
+	dest, err := wailsRuntime.SaveFileDialog(a.ctx, wailsRuntime.SaveDialogOptions{
+		Title:           "Export Logs",
+		DefaultFilename: "whisper-transcriber-logs.zip",
+		Filters: []wailsRuntime.FileFilter{
+			{DisplayName: "Zip archive", Pattern: "*.zip"},
+		},
+	})
+	if err != nil || dest == "" {
+		return "", err
+	}
+	if err := infrastructure.ExportLogs(infrastructure.LogDir(), dest); err != nil {
+		return "", fmt.Errorf("export logs: %w", err)
+	}
+	return dest, nil
+}
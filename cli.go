@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"whisper-transcriber/internal/infrastructure"
+	"whisper-transcriber/internal/service"
+	"whisper-transcriber/pkg/models"
+)
+
+// runCLI handles command-line subcommands. It reports false when args do not
+// name one, in which case the GUI starts.
+func runCLI(args []string) (code int, handled bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	switch args[0] {
+	case "convert":
+		return runConvert(args[1:]), true
+	case "eval":
+		return runEval(args[1:]), true
+	case "bench":
+		return runBench(args[1:]), true
+	}
+	return 0, false
+}
+
+func runConvert(args []string) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: whisper-transcriber convert -to FORMAT [options] INPUT")
+		fs.PrintDefaults()
+	}
+	var opts models.ConvertOptions
+	fs.StringVar(&opts.Format, "to", "", "output format: srt, vtt, ass, txt, json or md")
+	fs.StringVar(&opts.OutputPath, "o", "", "output file (default: input name with the new extension)")
+	fs.Float64Var(&opts.OffsetSec, "offset", 0, "shift all timings by this many seconds")
+	fps := fs.String("fps", "", "frame-rate conversion as FROM:TO, e.g. 25:23.976")
+	fs.IntVar(&opts.MaxLineChars, "wrap", 0, "re-wrap cue text to this many characters per line")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 || opts.Format == "" {
+		fs.Usage()
+		return 2
+	}
+	if *fps != "" {
+		if _, err := fmt.Sscanf(strings.Replace(*fps, ":", " ", 1), "%g %g", &opts.FromFPS, &opts.ToFPS); err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -fps %q: %v\n", *fps, err)
+			return 2
+		}
+	}
+
+	out, err := service.Convert(service.NewFormatter(), fs.Arg(0), opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "convert:", err)
+		return 1
+	}
+	fmt.Println(out)
+	return 0
+}
+
+func runEval(args []string) int {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: whisper-transcriber eval [options] DATASET_DIR")
+		fs.PrintDefaults()
+	}
+	cfg := models.EvalConfig{
+		Normalize: models.TextNormalization{Lowercase: true, StripPunctuation: true, Numbers: true},
+	}
+	appDir := infrastructure.AppDataDir()
+	modelPath := fs.String("model", "", "model file (default: the app's model)")
+	fs.StringVar(&cfg.Transcription.Language, "lang", "auto", "spoken language code or auto")
+	fs.IntVar(&cfg.Transcription.Concurrency.MaxThreads, "threads", 0, "CPU threads (default: all)")
+	fs.BoolVar(&cfg.Recursive, "r", false, "include subfolders")
+	fs.StringVar(&cfg.ReportDir, "report", filepath.Join(appDir, "evaluations"), "folder for the JSON and CSV reports")
+	keepCase := fs.Bool("keep-case", false, "compare case-sensitively")
+	keepPunct := fs.Bool("keep-punct", false, "keep punctuation when comparing")
+	keepNumbers := fs.Bool("keep-numbers", false, "do not convert number words to digits")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	cfg.Dir = fs.Arg(0)
+	cfg.Normalize.Lowercase = !*keepCase
+	cfg.Normalize.StripPunctuation = !*keepPunct
+	cfg.Normalize.Numbers = !*keepNumbers
+
+	ffmpeg := service.NewFFmpegService(appDir)
+	if !ffmpeg.IsAvailable() {
+		fmt.Fprintln(os.Stderr, "eval:", models.ErrFFmpegNotFound)
+		return 1
+	}
+	if *modelPath == "" {
+		*modelPath = service.NewModelManager(appDir).ModelPath()
+	}
+	transcriber := service.NewTranscriber()
+	defer transcriber.Close()
+	if err := transcriber.LoadModel(*modelPath); err != nil {
+		fmt.Fprintln(os.Stderr, "eval:", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	evaluator := service.NewEvaluator(transcriber, ffmpeg, cfg.ReportDir)
+	report, err := evaluator.Run(ctx, cfg, func(done, total int, path string) {
+		if path != "" {
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", done+1, total, filepath.Base(path))
+		}
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "eval:", err)
+		if report == nil {
+			return 1
+		}
+	}
+
+	for _, f := range report.Files {
+		if f.Error != "" {
+			fmt.Printf("%-40s error: %s\n", filepath.Base(f.Path), f.Error)
+			continue
+		}
+		fmt.Printf("%-40s WER %6.2f%%  CER %6.2f%%  RTF %.3f\n", filepath.Base(f.Path), f.WER*100, f.CER*100, f.RTF)
+	}
+	fmt.Printf("%-40s WER %6.2f%%  CER %6.2f%%  RTF %.3f\n", "TOTAL ("+report.Model+")", report.WER*100, report.CER*100, report.RTF)
+	for _, p := range report.ReportPaths {
+		fmt.Println(p)
+	}
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: whisper-transcriber bench [options] SAMPLE")
+		fs.PrintDefaults()
+	}
+	var cfg models.BenchmarkConfig
+	modelList := fs.String("models", "", "comma-separated model files (default: all installed)")
+	threadList := fs.String("threads", "", "comma-separated thread counts (default: 2, 4, ... up to the CPU count)")
+	fs.StringVar(&cfg.Language, "lang", "auto", "spoken language code or auto")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	cfg.SamplePath = fs.Arg(0)
+	for _, t := range strings.Split(*threadList, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		n, err := strconv.Atoi(t)
+		if err != nil || n < 1 {
+			fmt.Fprintf(os.Stderr, "invalid thread count %q\n", t)
+			return 2
+		}
+		cfg.Threads = append(cfg.Threads, n)
+	}
+
+	appDir := infrastructure.AppDataDir()
+	if *modelList != "" {
+		cfg.Models = strings.Split(*modelList, ",")
+	} else {
+		cfg.Models = service.NewModelManager(appDir).InstalledModels()
+	}
+	ffmpeg := service.NewFFmpegService(appDir)
+	if !ffmpeg.IsAvailable() {
+		fmt.Fprintln(os.Stderr, "bench:", models.ErrFFmpegNotFound)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	benchmarker := service.NewBenchmarker(ffmpeg, filepath.Join(appDir, "benchmarks.json"))
+	report, err := benchmarker.Run(ctx, cfg, func(done, total int, model string, threads int) {
+		if model != "" {
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s, %d threads\n", done+1, total, model, threads)
+		}
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "bench:", err)
+		if report == nil {
+			return 1
+		}
+	}
+
+	fmt.Printf("%-32s %7s %8s %8s %8s %7s %8s\n", "model", "threads", "load s", "encode s", "decode s", "RTF", "peak MB")
+	for _, r := range report.Results {
+		if r.Error != "" {
+			fmt.Printf("%-32s %7d error: %s\n", r.Model, r.Threads, r.Error)
+			continue
+		}
+		mem := "-"
+		if r.PeakMemMB > 0 {
+			mem = strconv.Itoa(r.PeakMemMB)
+		}
+		fmt.Printf("%-32s %7d %8.2f %8.2f %8.2f %7.3f %8s\n", r.Model, r.Threads, r.LoadSec, r.EncodeSec, r.DecodeSec, r.RTF, mem)
+	}
+	if rec, err := benchmarker.Recommend(); err == nil {
+		fmt.Printf("\nrecommended: %s with %d threads (%s)\n", rec.Model, rec.Threads, rec.Reason)
+	}
+	if err != nil {
+		return 1
+	}
+	return 0
+}
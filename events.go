@@ -18,13 +18,29 @@ func downloadProgressCb(ctx context.Context, event string) models.ProgressFunc {
 	}
 }
 
-func fileStatusCb(ctx context.Context) models.StatusFunc {
-	return func(fileID, status string, progress int, errMsg string) {
-		wailsRuntime.EventsEmit(ctx, "file:status", map[string]interface{}{
-			"fileID":   fileID,
-			"status":   status,
-			"progress": progress,
-			"error":    errMsg,
-		})
+func fileStatusCb(ctx context.Context) models.TimedStatusFunc {
+	return func(fileID, status string, progress int, err error, timing models.FileTiming) {
+		payload := map[string]interface{}{
+			"fileID":      fileID,
+			"status":      status,
+			"progress":    progress,
+			"error":       "",
+			"elapsedSec":  timing.ElapsedSec,
+			"etaSec":      timing.ETASec,
+			"batchEtaSec": timing.BatchETASec,
+		}
+		if err != nil {
+			info := models.DescribeError(err)
+			payload["error"] = info.Message
+			payload["errorCode"] = info.Code
+			payload["errorDetails"] = info.Details
+		}
+		wailsRuntime.EventsEmit(ctx, "file:status", payload)
 	}
 }
+
+// emitError sends err as a code, message and details so the UI can offer a
+// fix for known failures.
+func emitError(ctx context.Context, event string, err error) {
+	wailsRuntime.EventsEmit(ctx, event, models.DescribeError(err))
+}
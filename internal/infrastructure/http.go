@@ -3,6 +3,7 @@ package infrastructure
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -25,6 +26,7 @@ func HTTPGetWithRetry(ctx context.Context, url string, maxRetries int) (*http.Re
 
 		resp, err := http.DefaultClient.Do(req)
 		if err != nil {
+			slog.Warn("download attempt failed", "url", url, "attempt", attempt+1, "err", err)
 			lastErr = err
 			continue
 		}
@@ -34,6 +36,7 @@ func HTTPGetWithRetry(ctx context.Context, url string, maxRetries int) (*http.Re
 		}
 		resp.Body.Close()
 		lastErr = fmt.Errorf("HTTP %d", resp.StatusCode)
+		slog.Warn("download attempt failed", "url", url, "attempt", attempt+1, "err", lastErr)
 	}
 	return nil, fmt.Errorf("download failed after %d retries: %w", maxRetries, lastErr)
 }
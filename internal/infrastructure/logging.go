@@ -0,0 +1,214 @@
+package infrastructure
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+const (
+	logFileName   = "whisper-transcriber.log"
+	crashFileName = "crash.log"
+	maxLogSize    = 5 * 1024 * 1024
+	maxLogBackups = 3
+)
+
+func LogDir() string {
+	return filepath.Join(AppDataDir(), "logs")
+}
+
+// RotatingFile appends to a log file and, once it would grow past maxSize,
+// shifts it to .1, .2, ... keeping at most backups old files.
+type RotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	backups int
+	f       *os.File
+	size    int64
+}
+
+func OpenRotatingFile(path string, maxSize int64, backups int) (*RotatingFile, error) {
+	r := &RotatingFile{path: path, maxSize: maxSize, backups: backups}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate must be called with r.mu held.
+func (r *RotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	r.f = nil
+	for i := r.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if r.backups > 0 {
+		os.Rename(r.path, r.path+".1")
+	} else {
+		os.Remove(r.path)
+	}
+	return r.open()
+}
+
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// SetupLogging makes slog write to console and a rotating file in dir, and
+// routes Go crash reports to crash.log there. Output that native code writes
+// to stderr, such as whisper.cpp's, is captured into the log where supported.
+// The level can be overridden with WHISPER_TRANSCRIBER_LOG (debug, info,
+// warn or error).
+func SetupLogging(dir string) (*RotatingFile, error) {
+	level := slog.LevelInfo
+	if v := os.Getenv("WHISPER_TRANSCRIBER_LOG"); v != "" {
+		_ = level.UnmarshalText([]byte(v))
+	}
+
+	file, err := OpenRotatingFile(filepath.Join(dir, logFileName), maxLogSize, maxLogBackups)
+	if err != nil {
+		return nil, err
+	}
+	if crash, err := os.OpenFile(filepath.Join(dir, crashFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err == nil {
+		debug.SetCrashOutput(crash, debug.CrashOptions{})
+		crash.Close()
+	}
+
+	console, captureErr := captureStderr(func(line string) {
+		slog.Info(line, "source", "native")
+	})
+	if captureErr != nil {
+		console = os.Stderr
+	}
+	handler := slog.NewTextHandler(io.MultiWriter(console, file), &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(handler))
+	if captureErr != nil {
+		slog.Warn("native stderr is not captured", "err", captureErr)
+	}
+	return file, nil
+}
+
+// forwardLines passes each line read from r to onLine until r is closed.
+func forwardLines(r io.Reader, onLine func(string)) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 4096), 1024*1024)
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" {
+			onLine(line)
+		}
+	}
+}
+
+// ExportLogs zips the log directory into dest for attaching to bug reports.
+func ExportLogs(dir, dest string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(out)
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if err := addToZip(zw, filepath.Join(dir, e.Name())); err != nil {
+			zw.Close()
+			out.Close()
+			os.Remove(dest)
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return err
+	}
+	return out.Close()
+}
+
+func addToZip(zw *zip.Writer, path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	w, err := zw.Create(filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, in)
+	return err
+}
+
+// OpenPath shows a file or folder in the system file manager.
+func OpenPath(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("explorer", path)
+	case "darwin":
+		cmd = exec.Command("open", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
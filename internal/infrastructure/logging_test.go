@@ -0,0 +1,111 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		backups int
+		writes  []string
+		want    map[string]string
+	}{
+		{
+			name:    "no rotation below the limit",
+			backups: 2,
+			writes:  []string{"aaaa", "bbbb"},
+			want:    map[string]string{"": "aaaabbbb"},
+		},
+		{
+			name:    "rotates when a write would exceed the limit",
+			backups: 2,
+			writes:  []string{"aaaa", "bbbb", "cccc"},
+			want:    map[string]string{"": "cccc", ".1": "aaaabbbb"},
+		},
+		{
+			name:    "shifts backups and drops the oldest",
+			backups: 2,
+			writes:  []string{"aaaaaaaa", "bbbbbbbb", "cccccccc", "dddddddd"},
+			want:    map[string]string{"": "dddddddd", ".1": "cccccccc", ".2": "bbbbbbbb"},
+		},
+		{
+			name:    "no backups",
+			backups: 0,
+			writes:  []string{"aaaaaaaa", "bbbbbbbb"},
+			want:    map[string]string{"": "bbbbbbbb"},
+		},
+		{
+			name:    "oversized write goes to an empty file",
+			backups: 1,
+			writes:  []string{"aaaaaaaaaaaaaaaaaaaa"},
+			want:    map[string]string{"": "aaaaaaaaaaaaaaaaaaaa"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "logs", "app.log")
+			r, err := OpenRotatingFile(path, 10, tt.backups)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range tt.writes {
+				if _, err := r.Write([]byte(w)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			files, _ := filepath.Glob(path + "*")
+			if len(files) != len(tt.want) {
+				t.Errorf("got files %v, want %d", files, len(tt.want))
+			}
+			for suffix, want := range tt.want {
+				data, err := os.ReadFile(path + suffix)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != want {
+					t.Errorf("%s: got %q, want %q", "app.log"+suffix, data, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRotatingFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("12345678"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := OpenRotatingFile(path, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	// The existing size counts towards the limit.
+	fmt.Fprint(r, "abc")
+	if data, _ := os.ReadFile(path + ".1"); string(data) != "12345678" {
+		t.Errorf("backup %q", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "abc" {
+		t.Errorf("log %q", data)
+	}
+}
+
+func TestRotatingFileClosed(t *testing.T) {
+	r, err := OpenRotatingFile(filepath.Join(t.TempDir(), "app.log"), 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if _, err := r.Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("write after close: %v", err)
+	}
+}
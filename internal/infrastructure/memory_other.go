@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+
+package infrastructure
+
+// ResidentMemory is not implemented on this platform and returns 0, so
+// benchmarks leave memory use out. macOS only exposes the current size
+// through Mach calls that need cgo.
+func ResidentMemory() uint64 {
+	return 0
+}
@@ -0,0 +1,37 @@
+package infrastructure
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetProcessMemoryInfo = syscall.NewLazyDLL("psapi.dll").NewProc("GetProcessMemoryInfo")
+
+type processMemoryCounters struct {
+	cb                         uint32
+	pageFaultCount             uint32
+	peakWorkingSetSize         uintptr
+	workingSetSize             uintptr
+	quotaPeakPagedPoolUsage    uintptr
+	quotaPagedPoolUsage        uintptr
+	quotaPeakNonPagedPoolUsage uintptr
+	quotaNonPagedPoolUsage     uintptr
+	pagefileUsage              uintptr
+	peakPagefileUsage          uintptr
+}
+
+// ResidentMemory returns the process's current working set in bytes, or 0
+// if it cannot be read.
+func ResidentMemory() uint64 {
+	h, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return 0
+	}
+	var c processMemoryCounters
+	c.cb = uint32(unsafe.Sizeof(c))
+	r, _, _ := procGetProcessMemoryInfo.Call(uintptr(h), uintptr(unsafe.Pointer(&c)), uintptr(c.cb))
+	if r == 0 {
+		return 0
+	}
+	return uint64(c.workingSetSize)
+}
@@ -1,6 +1,7 @@
 package infrastructure
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 )
@@ -21,3 +22,36 @@ func AppDataDir() string {
 
 	return dir
 }
+
+// MoveFile renames src to dst, copying across volumes when a rename is not
+// possible.
+func MoveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
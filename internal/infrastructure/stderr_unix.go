@@ -0,0 +1,33 @@
+//go:build !windows
+
+package infrastructure
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// captureStderr points file descriptor 2 at a pipe whose lines go to onLine,
+// so output from C libraries reaches the log. It returns a file for the
+// original stderr.
+func captureStderr(onLine func(string)) (*os.File, error) {
+	orig, err := unix.Dup(int(os.Stderr.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		unix.Close(orig)
+		return nil, err
+	}
+	if err := unix.Dup2(int(w.Fd()), int(os.Stderr.Fd())); err != nil {
+		unix.Close(orig)
+		r.Close()
+		w.Close()
+		return nil, err
+	}
+	w.Close()
+	go forwardLines(r, onLine)
+	return os.NewFile(uintptr(orig), "stderr"), nil
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+
+package infrastructure
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCaptureStderr(t *testing.T) {
+	lines := make(chan string, 4)
+	orig, err := captureStderr(func(line string) { lines <- line })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		unix.Dup2(int(orig.Fd()), int(os.Stderr.Fd()))
+		orig.Close()
+	}()
+
+	// Native code writes to descriptor 2 directly, bypassing os.Stderr.
+	if _, err := syscall.Write(2, []byte("whisper_init: loading model\n\n  ggml: done  \n")); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"whisper_init: loading model", "ggml: done"} {
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+
+	// The returned file still reaches the original stderr.
+	if _, err := orig.Stat(); err != nil {
+		t.Errorf("original stderr: %v", err)
+	}
+}
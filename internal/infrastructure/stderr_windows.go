@@ -0,0 +1,13 @@
+package infrastructure
+
+import (
+	"errors"
+	"os"
+)
+
+// captureStderr is not supported on Windows: the C runtime binds its stderr
+// when the process starts, so replacing the handle later does not redirect
+// native output.
+func captureStderr(onLine func(string)) (*os.File, error) {
+	return nil, errors.New("not supported on windows")
+}
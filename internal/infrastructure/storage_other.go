@@ -0,0 +1,13 @@
+//go:build !windows
+
+package infrastructure
+
+import (
+	"errors"
+	"syscall"
+)
+
+// IsDiskFull reports whether err was caused by a full disk.
+func IsDiskFull(err error) bool {
+	return errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EDQUOT)
+}
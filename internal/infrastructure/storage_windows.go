@@ -0,0 +1,17 @@
+package infrastructure
+
+import (
+	"errors"
+	"syscall"
+)
+
+const (
+	errorHandleDiskFull syscall.Errno = 39
+	errorDiskFull       syscall.Errno = 112
+)
+
+// IsDiskFull reports whether err was caused by a full disk.
+func IsDiskFull(err error) bool {
+	var errno syscall.Errno
+	return errors.As(err, &errno) && (errno == errorDiskFull || errno == errorHandleDiskFull)
+}
@@ -0,0 +1,89 @@
+package infrastructure
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type storeRecord struct {
+	Name  string
+	Count int
+}
+
+func TestWriteJSONRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "store.json")
+	want := []storeRecord{{Name: "a", Count: 1}, {Name: "b", Count: 2}}
+	if err := WriteJSON(path, want); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+
+	var got []storeRecord
+	if err := ReadJSON(path, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestWriteJSONReplacesAtomically(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	if err := WriteJSON(path, storeRecord{Name: "old"}); err != nil {
+		t.Fatal(err)
+	}
+	// A stale temp file from an earlier crash is overwritten, not appended to.
+	if err := os.WriteFile(path+".tmp", []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteJSON(path, storeRecord{Name: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	var got storeRecord
+	if err := ReadJSON(path, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "new" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestWriteJSONFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "store.json")
+	if err := WriteJSON(path, storeRecord{Name: "old"}); err != nil {
+		t.Fatal(err)
+	}
+	// A directory where the temp file should go makes the write fail.
+	if err := os.Mkdir(path+".tmp", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteJSON(path, storeRecord{Name: "new"}); err == nil {
+		t.Fatal("want error")
+	}
+	var got storeRecord
+	if err := ReadJSON(path, &got); err != nil || got.Name != "old" {
+		t.Errorf("got %+v, %v; want the old record", got, err)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	dir := t.TempDir()
+	var v storeRecord
+	if err := ReadJSON(filepath.Join(dir, "missing.json"), &v); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file: %v", err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte(`{"Name": "trunc`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadJSON(corrupt, &v); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("corrupt file: %v", err)
+	}
+}
@@ -2,12 +2,30 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	"whisper-transcriber/pkg/models"
 )
 
-type BatchCompleteFunc func(fileID, outputPath string)
+// maxWorkers caps concurrent whisper instances; each holds its own copy of
+// the model in memory.
+const maxWorkers = 4
+
+const (
+	defaultMaxRetries = 2
+	defaultRetryDelay = 2 * time.Second
+)
+
+type BatchCompleteFunc func(fileID, outputPath string, result *models.TranscriptionResult)
 
 type BatchDoneFunc func()
 
@@ -16,6 +34,17 @@ type BatchProcessor struct {
 	ffmpeg      models.FFmpegService
 	formatter   models.Formatter
 	queue       models.FileQueue
+	cache       models.ResultCache
+	settings    models.ThroughputStore
+	filesGate   *PauseGate
+	windowsGate *PauseGate
+
+	mu      sync.Mutex
+	running bool
+	drained bool
+	active  map[string]*fileRun
+	skipped map[string]bool
+	started map[string]bool
 }
 
 func NewBatchProcessor(
@@ -23,63 +52,639 @@ func NewBatchProcessor(
 	ffmpeg models.FFmpegService,
 	formatter models.Formatter,
 	queue models.FileQueue,
+	cache models.ResultCache,
+	settings models.ThroughputStore,
 ) *BatchProcessor {
 	return &BatchProcessor{
 		transcriber: transcriber,
 		ffmpeg:      ffmpeg,
 		formatter:   formatter,
 		queue:       queue,
+		cache:       cache,
+		settings:    settings,
+		filesGate:   NewPauseGate(),
+		windowsGate: NewPauseGate(),
 	}
 }
 
 func (b *BatchProcessor) Run(
 	ctx context.Context,
 	config models.TranscriptionConfig,
-	onStatus models.StatusFunc,
+	reportStatus models.TimedStatusFunc,
 	onComplete BatchCompleteFunc,
 	onDone BatchDoneFunc,
 ) {
-	files := b.queue.Snapshot()
+	defer onDone()
+
+	b.mu.Lock()
+	b.running = true
+	b.drained = false
+	b.active = make(map[string]*fileRun)
+	b.skipped = make(map[string]bool)
+	b.started = make(map[string]bool)
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		for _, run := range b.active {
+			run.cancel()
+		}
+		b.running = false
+		b.active = nil
+		b.skipped = nil
+		b.started = nil
+		b.mu.Unlock()
+	}()
+
+	modelPath := b.transcriber.ModelPath()
+	workers := clampWorkers(config.Concurrency.Workers)
+	b.UpdateEstimates(modelPath)
+
+	batchStart := time.Now()
+	slog.Info("batch started", "model", filepath.Base(modelPath), "workers", workers,
+		"format", config.OutputFormat, "language", config.Language)
+	defer func() {
+		slog.Info("batch finished", "duration", time.Since(batchStart).Round(time.Millisecond), "cancelled", ctx.Err() != nil)
+	}()
+
+	// Mirror progress into the queue so it survives restarts.
+	eta := newETATracker(b.queue, workers)
+	onStatus := func(fileID, status string, progress int, err error) {
+		b.queue.UpdateStatus(fileID, status, progress, err)
+		reportStatus(fileID, status, progress, err, eta.timing(fileID, status, progress))
+	}
+	reportComplete := onComplete
+	onComplete = func(fileID, outputPath string, result *models.TranscriptionResult) {
+		b.queue.AddOutput(fileID, outputPath)
+		reportComplete(fileID, outputPath, result)
+	}
+
+	filters, err := PreprocessFilters(config.Preprocess)
+	if err != nil {
+		slog.Error("invalid preprocessing", "err", err)
+		for _, fileItem := range b.queue.Snapshot() {
+			if isRunnable(fileItem.Status) {
+				onStatus(fileItem.ID, "error", 0, err)
+			}
+		}
+		return
+	}
+
+	ffmpegThreads, whisperThreads := threadBudget(workers, config.Concurrency.MaxThreads)
+
+	// The buffer lets extraction run ahead of transcription by one file per
+	// worker without piling up temporary WAVs.
+	extracted := make(chan *trackJob, workers)
+
+	// The next file is taken from the live queue each time, so reordering
+	// and files added mid-run are honoured.
+	go func() {
+		defer close(extracted)
+		for {
+			if b.filesGate.Wait(ctx) != nil {
+				return
+			}
+			fileItem, ok := b.next()
+			if !ok {
+				return
+			}
+
+			run, ok := b.startFile(ctx, fileItem)
+			if !ok {
+				continue
+			}
+			for _, job := range run.jobs {
+				if run.failed() {
+					break
+				}
+				if err := b.filesGate.Wait(ctx); err != nil {
+					job.err = err
+					run.finish(job, onStatus, onComplete)
+					return
+				}
+				if config.UseCache {
+					b.lookupCache(job, modelPath, config, filters)
+				}
+				if job.cached == nil {
+					b.extractWithRetry(run.ctx, job, filters, ffmpegThreads, config.Retry, onStatus)
+				}
+				select {
+				case extracted <- job:
+				case <-ctx.Done():
+					removeWav(job)
+					job.err = ctx.Err()
+					run.finish(job, onStatus, onComplete)
+					return
+				}
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range extracted {
+				if job.err == nil && job.run.ctx.Err() != nil {
+					job.err = job.run.ctx.Err()
+				}
+				if job.err == nil {
+					b.transcribe(job.run.ctx, job, config, filters, whisperThreads, onStatus)
+				}
+				if job.err == nil && job.cached == nil {
+					b.recordThroughput(modelPath, job)
+				}
+				removeWav(job)
+				job.run.finish(job, onStatus, onComplete)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() == nil {
+		return
+	}
+	var unstarted []string
+	items := b.queue.Snapshot()
+	b.mu.Lock()
+	for _, fileItem := range items {
+		if !b.started[fileItem.ID] && isRunnable(fileItem.Status) {
+			unstarted = append(unstarted, fileItem.ID)
+		}
+	}
+	b.mu.Unlock()
+	for _, id := range unstarted {
+		onStatus(id, "cancelled", 0, nil)
+	}
+}
+
+// next claims the next runnable file. Once the queue has nothing left the
+// batch stops taking files, and Requeue reports false from then on.
+func (b *BatchProcessor) next() (models.FileItem, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	item, ok := b.queue.Next(b.started)
+	if !ok {
+		b.drained = true
+		return item, false
+	}
+	b.started[item.ID] = true
+	return item, true
+}
+
+// Requeue lets the running batch pick id up again after it was reset to
+// "pending". It reports false if no batch is taking files, in which case the
+// caller has to start another one.
+func (b *BatchProcessor) Requeue(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.running || b.drained {
+		return false
+	}
+	delete(b.started, id)
+	delete(b.skipped, id)
+	return true
+}
+
+// startFile gives the file its own context so CancelFile can stop it without
+// touching the rest of the batch.
+func (b *BatchProcessor) startFile(ctx context.Context, item models.FileItem) (*fileRun, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.skipped[item.ID] {
+		return nil, false
+	}
+	fileCtx, cancel := context.WithCancel(ctx)
+	run := newFileRun(fileCtx, item)
+	run.cancel = cancel
+	run.release = func() { b.release(run) }
+	b.active[item.ID] = run
+	return run, true
+}
+
+// release forgets a file once it has finished or failed and cancels its
+// context, stopping any tracks still running.
+func (b *BatchProcessor) release(run *fileRun) {
+	b.mu.Lock()
+	if b.active[run.item.ID] == run {
+		delete(b.active, run.item.ID)
+	}
+	b.mu.Unlock()
+	run.cancel()
+}
+
+// CancelFile stops a single file, killing its ffmpeg process or aborting
+// its transcription. It reports whether the file was running; a file still
+// waiting in the queue is skipped by the current batch instead.
+func (b *BatchProcessor) CancelFile(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if run, ok := b.active[id]; ok {
+		run.cancel()
+		return true
+	}
+	if b.running {
+		b.skipped[id] = true
+	}
+	return false
+}
+
+// Pause stops the batch from starting new files. With immediate set, running
+// transcriptions are also suspended before their next 30-second window.
+func (b *BatchProcessor) Pause(immediate bool) {
+	b.filesGate.Pause()
+	if immediate {
+		b.windowsGate.Pause()
+	}
+}
+
+func (b *BatchProcessor) Resume() {
+	b.windowsGate.Resume()
+	b.filesGate.Resume()
+}
+
+func (b *BatchProcessor) IsPaused() bool {
+	return b.filesGate.Paused()
+}
+
+func (b *BatchProcessor) extractWithRetry(
+	ctx context.Context,
+	job *trackJob,
+	filters []string,
+	threads int,
+	policy models.RetryConfig,
+	onStatus models.StatusFunc,
+) {
+	maxRetries := policy.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+	delay := time.Duration(policy.BaseDelayMs) * time.Millisecond
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
 
-	for _, fileItem := range files {
+	for attempt := 0; ; attempt++ {
+		b.extract(ctx, job, filters, threads, onStatus)
+
+		var transient *models.TransientError
+		if job.err == nil || attempt >= maxRetries || !errors.As(job.err, &transient) {
+			return
+		}
+
+		job.log.Warn("retrying extraction", "attempt", attempt+1, "delay", delay<<attempt, "err", job.err)
+		onStatus(job.run.item.ID, "retrying", 0, job.err)
 		select {
+		case <-time.After(delay << attempt):
 		case <-ctx.Done():
-			onStatus(fileItem.ID, "cancelled", 0, "")
-			onDone()
 			return
-		default:
 		}
+	}
+}
+
+func (b *BatchProcessor) extract(
+	ctx context.Context,
+	job *trackJob,
+	filters []string,
+	threads int,
+	onStatus models.StatusFunc,
+) {
+	id := job.run.item.ID
+	onStatus(id, "extracting", job.scale(0), nil)
+
+	extractCb := func(percent int, _, _ string) {
+		onStatus(id, "extracting", job.scale(percent), nil)
+	}
+
+	opts := models.ExtractOptions{
+		StreamIndex: models.DefaultStream,
+		Ranges:      job.run.item.Ranges,
+		Filters:     filters,
+		Threads:     threads,
+	}
+	if job.track != nil {
+		opts.StreamIndex = job.track.Index
+	}
+	if media := job.run.item.Media; media != nil {
+		opts.Duration = media.Duration
+	}
+
+	start := time.Now()
+	job.wavPath, job.err = b.ffmpeg.ExtractAudio(ctx, job.run.item.Path, opts, extractCb)
+	elapsed := time.Since(start)
+	job.workSec += elapsed.Seconds()
+	if job.err != nil {
+		job.log.Warn("extraction failed", "stage", "extract", "duration", elapsed.Round(time.Millisecond), "err", job.err)
+		return
+	}
+	job.audioSec = wavDuration(job.wavPath)
+	job.log.Info("extracted", "stage", "extract", "duration", elapsed.Round(time.Millisecond),
+		"audioSec", job.audioSec, "stream", opts.StreamIndex, "filters", len(filters))
+}
 
-		onStatus(fileItem.ID, "processing", 0, "")
+func (b *BatchProcessor) transcribe(
+	ctx context.Context,
+	job *trackJob,
+	config models.TranscriptionConfig,
+	filters []string,
+	threads int,
+	onStatus models.StatusFunc,
+) {
+	item := job.run.item
+	onStatus(item.ID, "processing", job.scale(0), nil)
+
+	progressCb := func(percent int, _, _ string) {
+		onStatus(item.ID, "processing", job.scale(percent), nil)
+	}
+
+	transcribeOpts := models.TranscribeOptions{
+		Language:    config.Language,
+		VAD:         config.VAD,
+		Cleanup:     config.Cleanup,
+		Diarization: config.Diarization,
+		TrimSilence: trimsSilence(config.Preprocess),
+		Threads:     threads,
+		Gate:        b.windowsGate,
+	}
 
-		wavPath, err := b.ffmpeg.ExtractAudio(ctx, fileItem.Path)
+	result := job.cached
+	if result == nil {
+		var err error
+		start := time.Now()
+		result, err = b.transcriber.TranscribeFile(ctx, item.ID, job.wavPath, transcribeOpts, progressCb)
+		elapsed := time.Since(start)
+		job.workSec += elapsed.Seconds()
 		if err != nil {
-			onStatus(fileItem.ID, "error", 0, err.Error())
-			continue
+			job.log.Warn("transcription failed", "stage", "transcribe", "duration", elapsed.Round(time.Millisecond), "err", err)
+			job.err = err
+			return
+		}
+		job.log.Info("transcribed", "stage", "transcribe", "duration", elapsed.Round(time.Millisecond),
+			"segments", len(result.Segments), "threads", threads)
+		result.Segments = remapSegments(result.Segments, item.Ranges)
+		if job.cacheKey != "" {
+			// The cache is best-effort; a failed write only costs a rerun.
+			_ = b.cache.Put(job.cacheKey, result)
 		}
+	}
+	result.FilePath = item.Path
+	result.Track = job.tag
+	result.Filters = filters
 
-		progressCb := func(percent int, _, _ string) {
-			onStatus(fileItem.ID, "processing", percent, "")
+	outputDir, format := outputTarget(item, config)
+	sourcePath := item.Path
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			job.err = err
+			return
 		}
+		sourcePath = filepath.Join(outputDir, filepath.Base(item.Path))
+	}
 
-		result, err := b.transcriber.TranscribeFile(ctx, fileItem.ID, wavPath, config.Language, progressCb)
+	outPath, err := b.formatter.WriteOutput(result, sourcePath, format)
+	if err != nil {
+		job.log.Error("write output failed", "stage", "output", "err", err)
+		job.err = err
+		return
+	}
+	job.output = fileOutput{path: outPath, result: result}
+}
 
-		os.Remove(wavPath)
+// outputTarget returns where and in which format the item's output goes: its
+// own settings if it has them, otherwise the batch's.
+func outputTarget(item models.FileItem, config models.TranscriptionConfig) (dir, format string) {
+	if item.OutputFormat != "" {
+		return item.OutputDir, item.OutputFormat
+	}
+	return config.OutputDir, config.OutputFormat
+}
 
-		if err != nil {
-			onStatus(fileItem.ID, "error", 0, err.Error())
-			continue
+// lookupCache keys the job for the result cache and loads a stored result,
+// which lets the job skip extraction and transcription.
+func (b *BatchProcessor) lookupCache(job *trackJob, modelPath string, config models.TranscriptionConfig, filters []string) {
+	item := job.run.item
+	if b.cache == nil || item.Hash == "" {
+		return
+	}
+	job.cacheKey = cacheKey(item, job.track, modelPath, config, filters)
+	if result, ok := b.cache.Get(job.cacheKey); ok {
+		job.log.Info("using cached result", "stage", "cache")
+		job.cached = result
+	}
+}
+
+// recordThroughput stores how fast the job's audio was extracted and
+// transcribed and refreshes the queue's estimates with the new average.
+func (b *BatchProcessor) recordThroughput(modelPath string, job *trackJob) {
+	if b.settings == nil {
+		return
+	}
+	if err := b.settings.RecordThroughput(filepath.Base(modelPath), job.audioSec, job.workSec); err != nil {
+		job.log.Warn("record throughput failed", "err", err)
+		return
+	}
+	b.UpdateEstimates(modelPath)
+}
+
+// UpdateEstimates recomputes the queue's time estimates from the throughput
+// recorded for modelPath, or a default speed if there is none yet.
+func (b *BatchProcessor) UpdateEstimates(modelPath string) {
+	speed := defaultSpeedFactor
+	if b.settings != nil {
+		if s, ok := b.settings.Throughput(filepath.Base(modelPath)); ok {
+			speed = s
 		}
+	}
+	b.queue.SetSpeed(speed)
+}
 
-		outPath, err := b.formatter.WriteOutput(result, fileItem.Path, config.OutputFormat)
-		if err != nil {
-			onStatus(fileItem.ID, "error", 0, err.Error())
-			continue
+func removeWav(job *trackJob) {
+	if job.wavPath != "" {
+		os.Remove(job.wavPath)
+		job.wavPath = ""
+	}
+}
+
+type fileOutput struct {
+	path   string
+	result *models.TranscriptionResult
+}
+
+// fileRun tracks a file whose tracks may be extracted and transcribed
+// concurrently.
+type fileRun struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	release func()
+	item    models.FileItem
+	jobs    []*trackJob
+	log     *slog.Logger
+	start   time.Time
+
+	mu        sync.Mutex
+	remaining int
+	err       error
+}
+
+type trackJob struct {
+	run   *fileRun
+	pos   int
+	track *models.AudioStream
+	tag   string
+	log   *slog.Logger
+
+	cacheKey string
+	cached   *models.TranscriptionResult
+	wavPath  string
+	audioSec float64
+	workSec  float64
+	output   fileOutput
+	err      error
+}
+
+func newFileRun(ctx context.Context, item models.FileItem) *fileRun {
+	tracks := selectedTracks(item)
+	tags := trackTags(tracks)
+
+	run := &fileRun{
+		ctx:       ctx,
+		item:      item,
+		remaining: len(tracks),
+		log:       slog.With("file", item.ID, "name", item.Name),
+		start:     time.Now(),
+	}
+	for i, track := range tracks {
+		job := &trackJob{run: run, pos: i, track: track, tag: tags[i], log: run.log}
+		if job.tag != "" {
+			job.log = run.log.With("track", job.tag)
+		}
+		run.jobs = append(run.jobs, job)
+	}
+	return run
+}
+
+// scale maps a track's progress onto the file's progress bar, giving each
+// track an equal share.
+func (j *trackJob) scale(percent int) int {
+	return (j.pos*100 + percent) / len(j.run.jobs)
+}
+
+func (r *fileRun) failed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err != nil
+}
+
+func (r *fileRun) finish(job *trackJob, onStatus models.StatusFunc, onComplete BatchCompleteFunc) {
+	r.mu.Lock()
+	if job.err != nil && r.err == nil {
+		r.err = job.err
+		r.mu.Unlock()
+		if r.ctx.Err() != nil {
+			r.log.Info("file cancelled", "duration", time.Since(r.start).Round(time.Millisecond))
+			onStatus(r.item.ID, "cancelled", 0, nil)
+		} else {
+			r.log.Error("file failed", "duration", time.Since(r.start).Round(time.Millisecond), "err", job.err)
+			onStatus(r.item.ID, "error", 0, job.err)
+		}
+		r.release()
+		return
+	}
+	r.remaining--
+	done := r.remaining == 0 && r.err == nil
+	r.mu.Unlock()
+
+	if !done {
+		return
+	}
+	r.log.Info("file done", "duration", time.Since(r.start).Round(time.Millisecond))
+	onStatus(r.item.ID, "done", 100, nil)
+	for _, j := range r.jobs {
+		onComplete(r.item.ID, j.output.path, j.output.result)
+	}
+	r.release()
+}
+
+func clampWorkers(n int) int {
+	if n < 1 {
+		return 1
+	}
+	if n > maxWorkers {
+		return maxWorkers
+	}
+	return n
+}
+
+// threadBudget splits the CPU between ffmpeg and the whisper workers so the
+// overlapping stages don't oversubscribe the machine.
+func threadBudget(workers, maxThreads int) (ffmpegThreads, whisperThreads int) {
+	total := maxThreads
+	if total <= 0 || total > runtime.NumCPU() {
+		total = runtime.NumCPU()
+	}
+	ffmpegThreads = min(2, total)
+	whisperThreads = max(1, (total-ffmpegThreads)/workers)
+	return ffmpegThreads, whisperThreads
+}
+
+// selectedTracks returns the audio streams chosen for the file. A nil entry
+// means ffmpeg's default stream.
+func selectedTracks(fileItem models.FileItem) []*models.AudioStream {
+	if fileItem.Media == nil || len(fileItem.AudioTracks) == 0 {
+		return []*models.AudioStream{nil}
+	}
+
+	var tracks []*models.AudioStream
+	for _, idx := range fileItem.AudioTracks {
+		for i := range fileItem.Media.AudioStreams {
+			if fileItem.Media.AudioStreams[i].Index == idx {
+				tracks = append(tracks, &fileItem.Media.AudioStreams[i])
+				break
+			}
 		}
+	}
+	if len(tracks) == 0 {
+		return []*models.AudioStream{nil}
+	}
+	return tracks
+}
 
-		onStatus(fileItem.ID, "done", 100, "")
-		onComplete(fileItem.ID, outPath)
+// trackTags names each track's output. A single track keeps the plain output
+// name; several tracks are tagged by language or title, falling back to the
+// stream index.
+func trackTags(tracks []*models.AudioStream) []string {
+	tags := make([]string, len(tracks))
+	if len(tracks) < 2 {
+		return tags
 	}
 
-	onDone()
+	seen := make(map[string]bool)
+	for i, track := range tracks {
+		tag := sanitizeTag(track.Language)
+		if tag == "" {
+			tag = sanitizeTag(track.Title)
+		}
+		switch {
+		case tag == "":
+			tag = fmt.Sprintf("track%d", track.Index)
+		case seen[tag]:
+			tag = fmt.Sprintf("%s-track%d", tag, track.Index)
+		}
+		seen[tag] = true
+		tags[i] = tag
+	}
+	return tags
+}
+
+func sanitizeTag(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.TrimSpace(s) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			sb.WriteRune(r)
+		case unicode.IsSpace(r):
+			sb.WriteRune('_')
+		}
+	}
+	return sb.String()
 }
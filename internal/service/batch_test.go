@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestOutputTarget(t *testing.T) {
+	config := models.TranscriptionConfig{OutputDir: "/out", OutputFormat: "srt"}
+	tests := []struct {
+		name       string
+		item       models.FileItem
+		wantDir    string
+		wantFormat string
+	}{
+		{name: "batch settings", wantDir: "/out", wantFormat: "srt"},
+		{name: "own settings", item: models.FileItem{OutputDir: "/watch", OutputFormat: "vtt"}, wantDir: "/watch", wantFormat: "vtt"},
+		{name: "own format next to the source", item: models.FileItem{OutputFormat: "txt"}, wantFormat: "txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, format := outputTarget(tt.item, config)
+			if dir != tt.wantDir || format != tt.wantFormat {
+				t.Errorf("got %q, %q; want %q, %q", dir, format, tt.wantDir, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestFileRunFinishCancelled(t *testing.T) {
+	item := models.FileItem{
+		ID:          "a",
+		Media:       &models.MediaInfo{AudioStreams: []models.AudioStream{{Index: 1}, {Index: 2}}},
+		AudioTracks: []int{1, 2},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	run := newFileRun(ctx, item)
+	released := 0
+	run.release = func() { released++ }
+
+	var statuses []string
+	onStatus := func(_, status string, _ int, _ error) { statuses = append(statuses, status) }
+	completed := 0
+	onComplete := func(string, string, *models.TranscriptionResult) { completed++ }
+
+	run.finish(run.jobs[0], onStatus, onComplete)
+	cancel()
+	run.jobs[1].err = ctx.Err()
+	run.finish(run.jobs[1], onStatus, onComplete)
+
+	if len(statuses) != 1 || statuses[0] != "cancelled" {
+		t.Errorf("statuses = %v, want [cancelled]", statuses)
+	}
+	if released != 1 {
+		t.Errorf("released %d times, want 1", released)
+	}
+	if completed != 0 {
+		t.Errorf("onComplete called %d times, want 0", completed)
+	}
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	whispercpp "github.com/ggerganov/whisper.cpp/bindings/go"
+	whisper "github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+
+	"whisper-transcriber/internal/infrastructure"
+	"whisper-transcriber/pkg/models"
+)
+
+const (
+	// recommendRTF is the slowest real-time factor a recommended model may
+	// have: it must transcribe at least twice as fast as real time.
+	recommendRTF = 0.5
+	// maxBenchmarkReports bounds the saved history.
+	maxBenchmarkReports = 20
+	memSampleEvery      = 100 * time.Millisecond
+	windowSec           = 30
+)
+
+// Benchmarker times each model at several thread counts on a sample and
+// keeps the reports so a model can be recommended for this machine. It loads
+// its own model instances, independent of the transcriber.
+type Benchmarker struct {
+	ffmpeg    models.FFmpegService
+	storePath string
+	mu        sync.Mutex
+}
+
+func NewBenchmarker(ffmpeg models.FFmpegService, storePath string) *Benchmarker {
+	return &Benchmarker{ffmpeg: ffmpeg, storePath: storePath}
+}
+
+func currentMachine() models.MachineInfo {
+	return models.MachineInfo{OS: runtime.GOOS, Arch: runtime.GOARCH, CPUs: runtime.NumCPU()}
+}
+
+// Run benchmarks cfg.Models on the sample and saves the report. A model that
+// fails to load or run is reported with its error.
+func (b *Benchmarker) Run(ctx context.Context, cfg models.BenchmarkConfig, onProgress func(done, total int, model string, threads int)) (*models.BenchmarkReport, error) {
+	if len(cfg.Models) == 0 {
+		return nil, fmt.Errorf("no models to benchmark")
+	}
+	threads := cfg.Threads
+	if len(threads) == 0 {
+		threads = defaultBenchmarkThreads(runtime.NumCPU())
+	}
+	language := cfg.Language
+	if language == "" {
+		language = "auto"
+	}
+
+	wavPath, err := b.ffmpeg.ExtractAudio(ctx, cfg.SamplePath, models.ExtractOptions{StreamIndex: models.DefaultStream}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("extract sample: %w", err)
+	}
+	defer os.Remove(wavPath)
+	samples, err := readWavSamples(wavPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("sample has no audio")
+	}
+	audioSec := float64(len(samples)) / whisper.SampleRate
+
+	report := &models.BenchmarkReport{
+		StartedAt: time.Now(),
+		Machine:   currentMachine(),
+		Sample:    cfg.SamplePath,
+	}
+	total := len(cfg.Models) * len(threads)
+	done := 0
+	for _, modelPath := range cfg.Models {
+		base := models.BenchmarkResult{
+			Model:     strings.TrimSuffix(filepath.Base(modelPath), filepath.Ext(modelPath)),
+			ModelPath: modelPath,
+			AudioSec:  audioSec,
+		}
+		if info, err := os.Stat(modelPath); err == nil {
+			base.ModelSizeMB = int(info.Size() / (1024 * 1024))
+		}
+
+		results, err := b.benchModel(ctx, base, samples, language, threads, func(n int) {
+			if onProgress != nil {
+				onProgress(done, total, base.Model, n)
+			}
+			done++
+		})
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			slog.Warn("benchmark failed", "model", base.Model, "err", err)
+			base.Error = err.Error()
+			results = []models.BenchmarkResult{base}
+			done += len(threads)
+		}
+		for _, r := range results {
+			if r.Error == "" {
+				slog.Info("benchmarked", "model", r.Model, "threads", r.Threads, "loadSec", r.LoadSec, "rtf", r.RTF, "peakMemMB", r.PeakMemMB)
+			}
+		}
+		report.Results = append(report.Results, results...)
+	}
+	if onProgress != nil {
+		onProgress(total, total, "", 0)
+	}
+
+	if err := b.save(report); err != nil {
+		return report, fmt.Errorf("save benchmark: %w", err)
+	}
+	return report, nil
+}
+
+func (b *Benchmarker) benchModel(
+	ctx context.Context,
+	base models.BenchmarkResult,
+	samples []float32,
+	language string,
+	threads []int,
+	starting func(threads int),
+) ([]models.BenchmarkResult, error) {
+	// Memory is reported above what the process used before the model was
+	// loaded, so earlier models and the app itself are not counted.
+	baseline := infrastructure.ResidentMemory()
+	peak := watchPeakMemory()
+	start := time.Now()
+	model, err := whisper.New(base.ModelPath)
+	loadSec := time.Since(start).Seconds()
+	loadPeak := peak()
+	if err != nil {
+		return nil, fmt.Errorf("load model: %w", err)
+	}
+	defer model.Close()
+
+	var results []models.BenchmarkResult
+	for _, n := range threads {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		starting(n)
+		res := base
+		res.Threads = n
+		res.LoadSec = loadSec
+
+		peak := watchPeakMemory()
+		err := timeTranscription(ctx, model, samples, language, n, &res)
+		if used := max(loadPeak, peak()); used > baseline {
+			res.PeakMemMB = int((used - baseline) / (1024 * 1024))
+		}
+		if err != nil {
+			res.Error = err.Error()
+		}
+		results = append(results, res)
+	}
+
+	// The encoder is timed on a separate low-level context, loaded after the
+	// runs above so it does not affect their memory peaks.
+	encodeWindow := measureEncoder(base.ModelPath, samples, threads)
+	windows := math.Ceil(base.AudioSec / windowSec)
+	for i := range results {
+		if results[i].Error != "" {
+			continue
+		}
+		if sec, ok := encodeWindow[results[i].Threads]; ok {
+			results[i].EncodeSec = min(sec*windows, results[i].TranscribeSec)
+			results[i].DecodeSec = results[i].TranscribeSec - results[i].EncodeSec
+		}
+	}
+	return results, nil
+}
+
+func timeTranscription(ctx context.Context, model whisper.Model, samples []float32, language string, threads int, res *models.BenchmarkResult) error {
+	wctx, err := model.NewContext()
+	if err != nil {
+		return err
+	}
+	if err := wctx.SetLanguage(language); err != nil {
+		return err
+	}
+	wctx.SetThreads(uint(threads))
+
+	start := time.Now()
+	err = wctx.Process(samples, func() bool { return ctx.Err() == nil }, nil, nil)
+	res.TranscribeSec = time.Since(start).Seconds()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+	res.RTF = res.TranscribeSec / res.AudioSec
+	return nil
+}
+
+// measureEncoder returns the time to encode one 30-second window at each
+// thread count. Counts it could not measure are left out.
+func measureEncoder(modelPath string, samples []float32, threads []int) map[int]float64 {
+	out := make(map[int]float64)
+	wctx := whispercpp.Whisper_init(modelPath)
+	if wctx == nil {
+		return out
+	}
+	defer wctx.Whisper_free()
+
+	window := encoderWindow(samples)
+	for _, n := range threads {
+		if err := wctx.Whisper_pcm_to_mel(window, n); err != nil {
+			return out
+		}
+		start := time.Now()
+		if err := wctx.Whisper_encode(0, n); err != nil {
+			continue
+		}
+		out[n] = time.Since(start).Seconds()
+	}
+	return out
+}
+
+// encoderWindow returns exactly one 30-second window of samples. Shorter
+// clips are padded with silence, since the encoder always processes a full
+// window and the per-window time is scaled up by the window count.
+func encoderWindow(samples []float32) []float32 {
+	window := make([]float32, windowSec*whisper.SampleRate)
+	copy(window, samples)
+	return window
+}
+
+// watchPeakMemory samples resident memory until the returned function is
+// called, which stops sampling and reports the highest value seen.
+func watchPeakMemory() func() uint64 {
+	peak := infrastructure.ResidentMemory()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(memSampleEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				peak = max(peak, infrastructure.ResidentMemory())
+			}
+		}
+	}()
+	return func() uint64 {
+		close(stop)
+		<-done
+		return max(peak, infrastructure.ResidentMemory())
+	}
+}
+
+// defaultBenchmarkThreads doubles from 2 up to the CPU count, which is
+// always included.
+func defaultBenchmarkThreads(cpus int) []int {
+	var threads []int
+	for n := 2; n < cpus; n *= 2 {
+		threads = append(threads, n)
+	}
+	return append(threads, max(cpus, 1))
+}
+
+// Reports returns the saved benchmark reports, newest first.
+func (b *Benchmarker) Reports() []models.BenchmarkReport {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	reports := b.load()
+	sort.SliceStable(reports, func(i, j int) bool { return reports[i].StartedAt.After(reports[j].StartedAt) })
+	return reports
+}
+
+// Recommend picks the largest benchmarked model that runs at least twice as
+// fast as real time on this machine, with its fastest thread count. If none
+// is fast enough it falls back to the fastest model. Newer reports replace
+// older measurements of the same model.
+func (b *Benchmarker) Recommend() (*models.ModelRecommendation, error) {
+	machine := currentMachine()
+	best := make(map[string]models.BenchmarkResult)
+	for _, report := range b.Reports() {
+		if report.Machine != machine {
+			continue
+		}
+		fromReport := make(map[string]models.BenchmarkResult)
+		for _, r := range report.Results {
+			if r.Error != "" || r.RTF <= 0 {
+				continue
+			}
+			if _, newer := best[r.ModelPath]; newer {
+				continue
+			}
+			if prev, ok := fromReport[r.ModelPath]; !ok || r.RTF < prev.RTF {
+				fromReport[r.ModelPath] = r
+			}
+		}
+		for path, r := range fromReport {
+			best[path] = r
+		}
+	}
+	if len(best) == 0 {
+		return nil, fmt.Errorf("no benchmark results for this machine — run a benchmark first")
+	}
+
+	var candidates []models.BenchmarkResult
+	for _, r := range best {
+		if _, err := os.Stat(r.ModelPath); err == nil {
+			candidates = append(candidates, r)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("none of the benchmarked models are installed")
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ModelSizeMB > candidates[j].ModelSizeMB })
+
+	for _, r := range candidates {
+		if r.RTF <= recommendRTF {
+			return recommendation(r, fmt.Sprintf("largest model that runs %.1fx faster than real time", 1/r.RTF)), nil
+		}
+	}
+	fastest := candidates[0]
+	for _, r := range candidates[1:] {
+		if r.RTF < fastest.RTF {
+			fastest = r
+		}
+	}
+	return recommendation(fastest, fmt.Sprintf("no model reaches 2x real time; this is the fastest (RTF %.2f)", fastest.RTF)), nil
+}
+
+func recommendation(r models.BenchmarkResult, reason string) *models.ModelRecommendation {
+	return &models.ModelRecommendation{
+		Model:     r.Model,
+		ModelPath: r.ModelPath,
+		Threads:   r.Threads,
+		RTF:       r.RTF,
+		Reason:    reason,
+	}
+}
+
+func (b *Benchmarker) save(report *models.BenchmarkReport) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	reports := append(b.load(), *report)
+	if len(reports) > maxBenchmarkReports {
+		reports = reports[len(reports)-maxBenchmarkReports:]
+	}
+	return infrastructure.WriteJSON(b.storePath, reports)
+}
+
+func (b *Benchmarker) load() []models.BenchmarkReport {
+	var reports []models.BenchmarkReport
+	_ = infrastructure.ReadJSON(b.storePath, &reports)
+	return reports
+}
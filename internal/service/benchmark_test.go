@@ -0,0 +1,153 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	whisper "github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
+
+	"whisper-transcriber/internal/infrastructure"
+	"whisper-transcriber/pkg/models"
+)
+
+func TestDefaultBenchmarkThreads(t *testing.T) {
+	tests := []struct {
+		cpus int
+		want []int
+	}{
+		{cpus: 0, want: []int{1}},
+		{cpus: 1, want: []int{1}},
+		{cpus: 2, want: []int{2}},
+		{cpus: 6, want: []int{2, 4, 6}},
+		{cpus: 8, want: []int{2, 4, 8}},
+		{cpus: 12, want: []int{2, 4, 8, 12}},
+	}
+	for _, tt := range tests {
+		if got := defaultBenchmarkThreads(tt.cpus); !slices.Equal(got, tt.want) {
+			t.Errorf("%d CPUs: got %v, want %v", tt.cpus, got, tt.want)
+		}
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	dir := t.TempDir()
+	model := func(name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tiny, base, small := model("ggml-tiny.bin"), model("ggml-base.bin"), model("ggml-small.bin")
+	large := filepath.Join(dir, "ggml-large.bin") // benchmarked, then deleted
+
+	result := func(path string, sizeMB, threads int, rtf float64) models.BenchmarkResult {
+		return models.BenchmarkResult{Model: filepath.Base(path), ModelPath: path, ModelSizeMB: sizeMB, Threads: threads, RTF: rtf}
+	}
+	day := func(n int) time.Time { return time.Date(2026, 1, n, 0, 0, 0, 0, time.UTC) }
+	here := currentMachine()
+	other := models.MachineInfo{OS: "plan9", Arch: here.Arch, CPUs: here.CPUs}
+
+	tests := []struct {
+		name        string
+		reports     []models.BenchmarkReport
+		wantPath    string
+		wantThreads int
+		wantErr     bool
+	}{
+		{name: "no reports", wantErr: true},
+		{
+			name: "largest fast enough model at its fastest thread count",
+			reports: []models.BenchmarkReport{{StartedAt: day(1), Machine: here, Results: []models.BenchmarkResult{
+				result(tiny, 75, 4, 0.05), result(base, 142, 2, 0.4), result(base, 142, 4, 0.2),
+				result(small, 466, 4, 0.9), result(large, 2900, 4, 0.1),
+			}}},
+			wantPath: base, wantThreads: 4,
+		},
+		{
+			name: "falls back to the fastest model",
+			reports: []models.BenchmarkReport{{StartedAt: day(1), Machine: here, Results: []models.BenchmarkResult{
+				result(base, 142, 4, 0.8), result(small, 466, 4, 1.5),
+			}}},
+			wantPath: base, wantThreads: 4,
+		},
+		{
+			name: "newer report wins even when slower",
+			reports: []models.BenchmarkReport{
+				{StartedAt: day(2), Machine: here, Results: []models.BenchmarkResult{result(small, 466, 2, 0.7)}},
+				{StartedAt: day(1), Machine: here, Results: []models.BenchmarkResult{result(small, 466, 8, 0.3), result(tiny, 75, 8, 0.1)}},
+			},
+			wantPath: tiny, wantThreads: 8,
+		},
+		{
+			name: "failed results and other machines ignored",
+			reports: []models.BenchmarkReport{
+				{StartedAt: day(3), Machine: other, Results: []models.BenchmarkResult{result(small, 466, 4, 0.1)}},
+				{StartedAt: day(2), Machine: here, Results: []models.BenchmarkResult{{Model: "ggml-small.bin", ModelPath: small, ModelSizeMB: 466, Threads: 4, Error: "load failed"}}},
+				{StartedAt: day(1), Machine: here, Results: []models.BenchmarkResult{result(tiny, 75, 2, 0.2)}},
+			},
+			wantPath: tiny, wantThreads: 2,
+		},
+		{
+			name:    "no results for this machine",
+			reports: []models.BenchmarkReport{{StartedAt: day(1), Machine: other, Results: []models.BenchmarkResult{result(tiny, 75, 2, 0.2)}}},
+			wantErr: true,
+		},
+		{
+			name:    "benchmarked models no longer installed",
+			reports: []models.BenchmarkReport{{StartedAt: day(1), Machine: here, Results: []models.BenchmarkResult{result(large, 2900, 4, 0.1)}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := filepath.Join(t.TempDir(), "benchmarks.json")
+			if tt.reports != nil {
+				if err := infrastructure.WriteJSON(store, tt.reports); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := NewBenchmarker(nil, store).Recommend()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.ModelPath != tt.wantPath || got.Threads != tt.wantThreads {
+				t.Errorf("got %s with %d threads, want %s with %d", got.ModelPath, got.Threads, tt.wantPath, tt.wantThreads)
+			}
+		})
+	}
+}
+
+func TestEncoderWindow(t *testing.T) {
+	size := windowSec * whisper.SampleRate
+	tests := []struct {
+		name string
+		in   int
+	}{
+		{name: "short clip padded", in: size / 3},
+		{name: "exact window", in: size},
+		{name: "long clip trimmed", in: size * 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			samples := make([]float32, tt.in)
+			for i := range samples {
+				samples[i] = 1
+			}
+			got := encoderWindow(samples)
+			if len(got) != size {
+				t.Fatalf("len = %d, want %d", len(got), size)
+			}
+			filled := min(tt.in, size)
+			if got[filled-1] != 1 || (filled < size && got[filled] != 0) {
+				t.Errorf("window does not start with the clip followed by silence")
+			}
+		})
+	}
+}
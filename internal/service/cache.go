@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"whisper-transcriber/internal/infrastructure"
+	"whisper-transcriber/pkg/models"
+)
+
+const hashChunkSize = 4 << 20
+
+// hashFile returns the SHA-256 of the file content. It reads in chunks and
+// checks ctx between them so hashing a large recording can be abandoned.
+// onProgress, if set, is called with the percentage read when it changes.
+func hashFile(ctx context.Context, path string, onProgress func(int)) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	buf := make([]byte, hashChunkSize)
+	var read int64
+	last := -1
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		n, err := f.Read(buf)
+		h.Write(buf[:n])
+		read += int64(n)
+		if onProgress != nil && info.Size() > 0 {
+			if pct := int(read * 100 / info.Size()); pct != last {
+				last = pct
+				onProgress(min(pct, 100))
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cacheKey identifies a result by everything that shapes it: the content,
+// the model, the selected audio and the transcription settings. The output
+// format is not part of it since results are stored before formatting.
+func cacheKey(item models.FileItem, track *models.AudioStream, modelPath string, config models.TranscriptionConfig, filters []string) string {
+	stream := models.DefaultStream
+	if track != nil {
+		stream = track.Index
+	}
+	data, _ := json.Marshal(struct {
+		Hash        string
+		Model       string
+		Stream      int
+		Ranges      []models.TimeRange
+		Filters     []string
+		Language    string
+		VAD         models.VADConfig
+		Cleanup     string
+		Diarization models.DiarizationConfig
+		TrimSilence bool
+	}{
+		Hash:        item.Hash,
+		Model:       filepath.Base(modelPath),
+		Stream:      stream,
+		Ranges:      item.Ranges,
+		Filters:     filters,
+		Language:    config.Language,
+		VAD:         config.VAD,
+		Cleanup:     config.Cleanup,
+		Diarization: config.Diarization,
+		TrimSilence: trimsSilence(config.Preprocess),
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ResultCache keeps one JSON file per key under dir.
+type ResultCache struct {
+	dir string
+}
+
+func NewResultCache(dir string) *ResultCache {
+	return &ResultCache{dir: dir}
+}
+
+func (c *ResultCache) Get(key string) (*models.TranscriptionResult, bool) {
+	var result models.TranscriptionResult
+	if err := infrastructure.ReadJSON(c.path(key), &result); err != nil {
+		return nil, false
+	}
+	return &result, true
+}
+
+func (c *ResultCache) Put(key string, result *models.TranscriptionResult) error {
+	return infrastructure.WriteJSON(c.path(key), result)
+}
+
+func (c *ResultCache) Clear() error {
+	if err := os.RemoveAll(c.dir); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (c *ResultCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
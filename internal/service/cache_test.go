@@ -0,0 +1,145 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestHashFile(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), hashChunkSize/4)
+	path := filepath.Join(t.TempDir(), "a.wav")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var progress []int
+	got, err := hashFile(context.Background(), path, func(pct int) { progress = append(progress, pct) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	if want := hex.EncodeToString(sum[:]); got != want {
+		t.Errorf("hash %s, want %s", got, want)
+	}
+	if len(progress) < 2 || progress[len(progress)-1] != 100 {
+		t.Errorf("progress %v", progress)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := hashFile(ctx, path, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: %v", err)
+	}
+	if _, err := hashFile(context.Background(), filepath.Join(t.TempDir(), "missing"), nil); err == nil {
+		t.Error("missing file: want error")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	item := models.FileItem{Hash: "abc", Ranges: []models.TimeRange{{Start: 1, End: 2}}}
+	config := models.TranscriptionConfig{Language: "en", OutputFormat: "srt"}
+	base := cacheKey(item, nil, "/models/ggml-base.bin", config, []string{"highpass=f=80"})
+
+	same := []struct {
+		name string
+		key  string
+	}{
+		{name: "model directory", key: cacheKey(item, nil, "/other/ggml-base.bin", config, []string{"highpass=f=80"})},
+		{name: "output format", key: cacheKey(item, nil, "/models/ggml-base.bin", models.TranscriptionConfig{Language: "en", OutputFormat: "vtt"}, []string{"highpass=f=80"})},
+		{name: "file name", key: cacheKey(models.FileItem{Hash: "abc", Name: "renamed.wav", Ranges: item.Ranges}, nil, "/models/ggml-base.bin", config, []string{"highpass=f=80"})},
+	}
+	for _, tt := range same {
+		if tt.key != base {
+			t.Errorf("%s changed the key", tt.name)
+		}
+	}
+
+	different := []struct {
+		name string
+		key  string
+	}{
+		{name: "content", key: cacheKey(models.FileItem{Hash: "abd", Ranges: item.Ranges}, nil, "/models/ggml-base.bin", config, []string{"highpass=f=80"})},
+		{name: "model", key: cacheKey(item, nil, "/models/ggml-small.bin", config, []string{"highpass=f=80"})},
+		{name: "track", key: cacheKey(item, &models.AudioStream{Index: 2}, "/models/ggml-base.bin", config, []string{"highpass=f=80"})},
+		{name: "ranges", key: cacheKey(models.FileItem{Hash: "abc"}, nil, "/models/ggml-base.bin", config, []string{"highpass=f=80"})},
+		{name: "filters", key: cacheKey(item, nil, "/models/ggml-base.bin", config, nil)},
+		{name: "language", key: cacheKey(item, nil, "/models/ggml-base.bin", models.TranscriptionConfig{Language: "de", OutputFormat: "srt"}, []string{"highpass=f=80"})},
+		{name: "trim silence", key: cacheKey(item, nil, "/models/ggml-base.bin", models.TranscriptionConfig{Language: "en", OutputFormat: "srt", Preprocess: models.PreprocessConfig{TrimSilence: true}}, []string{"highpass=f=80"})},
+		{name: "vad", key: cacheKey(item, nil, "/models/ggml-base.bin", models.TranscriptionConfig{Language: "en", OutputFormat: "srt", VAD: models.VADConfig{Enabled: true}}, []string{"highpass=f=80"})},
+	}
+	for _, tt := range different {
+		if tt.key == base {
+			t.Errorf("%s did not change the key", tt.name)
+		}
+	}
+}
+
+func TestResultCache(t *testing.T) {
+	cache := NewResultCache(filepath.Join(t.TempDir(), "cache"))
+	if _, ok := cache.Get("k"); ok {
+		t.Fatal("hit on an empty cache")
+	}
+	want := &models.TranscriptionResult{Language: "en", Segments: []models.Segment{{End: 1, Text: "hi"}}}
+	if err := cache.Put("k", want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := cache.Get("k")
+	if !ok || got.Language != "en" || len(got.Segments) != 1 || got.Segments[0].Text != "hi" {
+		t.Fatalf("got %+v, %v", got, ok)
+	}
+	if err := cache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.Get("k"); ok {
+		t.Error("hit after Clear")
+	}
+	if err := cache.Clear(); err != nil {
+		t.Errorf("clearing twice: %v", err)
+	}
+}
+
+func TestLookupCache(t *testing.T) {
+	cache := NewResultCache(t.TempDir())
+	config := models.TranscriptionConfig{Language: "en"}
+	item := models.FileItem{ID: "a", Hash: "abc"}
+	stored := &models.TranscriptionResult{Segments: []models.Segment{{Text: "cached"}}}
+	if err := cache.Put(cacheKey(item, nil, "ggml-base.bin", config, nil), stored); err != nil {
+		t.Fatal(err)
+	}
+	b := &BatchProcessor{cache: cache}
+
+	tests := []struct {
+		name    string
+		item    models.FileItem
+		config  models.TranscriptionConfig
+		wantKey bool
+		wantHit bool
+	}{
+		{name: "hit", item: item, config: config, wantKey: true, wantHit: true},
+		{name: "miss", item: item, config: models.TranscriptionConfig{Language: "de"}, wantKey: true},
+		{name: "not hashed", item: models.FileItem{ID: "a"}, config: config},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := newFileRun(context.Background(), tt.item).jobs[0]
+			b.lookupCache(job, "/models/ggml-base.bin", tt.config, nil)
+			if (job.cacheKey != "") != tt.wantKey {
+				t.Errorf("key %q", job.cacheKey)
+			}
+			if (job.cached != nil) != tt.wantHit {
+				t.Errorf("cached %+v", job.cached)
+			}
+			if tt.wantHit && job.cached.Segments[0].Text != "cached" {
+				t.Errorf("cached %+v", job.cached)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"whisper-transcriber/pkg/models"
+)
+
+// Convert rewrites a subtitle or transcript file in another format without
+// touching the model or ffmpeg, and returns the output path.
+func Convert(formatter models.Formatter, inputPath string, opts models.ConvertOptions) (string, error) {
+	if (opts.FromFPS > 0) != (opts.ToFPS > 0) {
+		return "", fmt.Errorf("frame-rate conversion needs both a source and a target rate")
+	}
+
+	result, err := ParseTranscript(inputPath)
+	if err != nil {
+		return "", err
+	}
+	retime(result, opts)
+	if opts.MaxLineChars > 0 {
+		for i := range result.Segments {
+			result.Segments[i].Text = wrapText(result.Segments[i].Text, opts.MaxLineChars)
+		}
+	}
+
+	content, err := formatter.Render(result, opts.Format)
+	if err != nil {
+		return "", err
+	}
+
+	outPath := opts.OutputPath
+	if outPath == "" {
+		outPath = strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + "." + opts.Format
+	}
+	if samePath(inputPath, outPath) {
+		return "", fmt.Errorf("output would overwrite %s; choose an output path", filepath.Base(inputPath))
+	}
+	return outPath, os.WriteFile(outPath, []byte(content), 0644)
+}
+
+// retime rescales timings between frame rates, then applies the offset.
+// Segments pushed entirely before zero are dropped.
+func retime(result *models.TranscriptionResult, opts models.ConvertOptions) {
+	scale := 1.0
+	if opts.FromFPS > 0 && opts.ToFPS > 0 {
+		scale = opts.FromFPS / opts.ToFPS
+	}
+	if scale == 1 && opts.OffsetSec == 0 {
+		return
+	}
+
+	segs := result.Segments[:0]
+	for _, seg := range result.Segments {
+		seg.Start = seg.Start*scale + opts.OffsetSec
+		seg.End = seg.End*scale + opts.OffsetSec
+		if seg.End <= 0 {
+			continue
+		}
+		seg.Start = max(0, seg.Start)
+		segs = append(segs, seg)
+	}
+	result.Segments = segs
+	renumber(result)
+}
+
+// wrapText re-breaks text into lines of at most width runes, splitting only
+// at spaces; a longer word gets a line of its own.
+func wrapText(text string, width int) string {
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(text) {
+		w := []rune(word)
+		if len(line) > 0 && len(line)+1+len(w) > width {
+			lines = append(lines, string(line))
+			line = nil
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, w...)
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func samePath(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
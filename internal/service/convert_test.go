@@ -0,0 +1,79 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestRetime(t *testing.T) {
+	segments := []models.Segment{
+		{Index: 0, Start: 0, End: 1, Text: "a"},
+		{Index: 1, Start: 1, End: 2.5, Text: "b"},
+		{Index: 2, Start: 4, End: 5, Text: "c"},
+	}
+	tests := []struct {
+		name string
+		opts models.ConvertOptions
+		want [][2]float64
+	}{
+		{name: "unchanged", want: [][2]float64{{0, 1}, {1, 2.5}, {4, 5}}},
+		{name: "offset", opts: models.ConvertOptions{OffsetSec: 1.5}, want: [][2]float64{{1.5, 2.5}, {2.5, 4}, {5.5, 6.5}}},
+		{name: "negative offset drops and clamps", opts: models.ConvertOptions{OffsetSec: -2}, want: [][2]float64{{0, 0.5}, {2, 3}}},
+		{name: "frame rate", opts: models.ConvertOptions{FromFPS: 50, ToFPS: 25}, want: [][2]float64{{0, 2}, {2, 5}, {8, 10}}},
+		{name: "frame rate then offset", opts: models.ConvertOptions{FromFPS: 25, ToFPS: 50, OffsetSec: 1}, want: [][2]float64{{1, 1.5}, {1.5, 2.25}, {3, 3.5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &models.TranscriptionResult{Segments: append([]models.Segment(nil), segments...)}
+			retime(result, tt.opts)
+			if len(result.Segments) != len(tt.want) {
+				t.Fatalf("got %+v, want %v", result.Segments, tt.want)
+			}
+			for i, seg := range result.Segments {
+				if seg.Start != tt.want[i][0] || seg.End != tt.want[i][1] {
+					t.Errorf("segment %d: got %v-%v, want %v-%v", i, seg.Start, seg.End, tt.want[i][0], tt.want[i][1])
+				}
+				if seg.Index != i {
+					t.Errorf("segment %d has index %d", i, seg.Index)
+				}
+			}
+		})
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  string
+	}{
+		{name: "fits", text: "short line", width: 20, want: "short line"},
+		{name: "wraps at spaces", text: "the quick brown fox jumps", width: 10, want: "the quick\nbrown fox\njumps"},
+		{name: "exact width", text: "abcde fghij", width: 5, want: "abcde\nfghij"},
+		{name: "long word alone", text: "a extraordinarily b", width: 6, want: "a\nextraordinarily\nb"},
+		{name: "existing breaks", text: "one\ntwo  three", width: 20, want: "one two three"},
+		{name: "counts runes", text: "привет мир", width: 10, want: "привет мир"},
+		{name: "empty", text: "  ", width: 10, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapText(tt.text, tt.width); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertRefusesToOverwriteInput(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "talk.srt")
+	if err := os.WriteFile(input, []byte("1\n00:00:01,000 --> 00:00:02,000\nHi\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Convert(NewFormatter(), input, models.ConvertOptions{Format: "srt"}); err == nil {
+		t.Fatal("want error when the output is the input")
+	}
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"whisper-transcriber/pkg/models"
+)
+
+const (
+	diarFrameLen        = 400 // 25 ms at 16 kHz
+	diarHopLen          = 160 // 10 ms at 16 kHz
+	diarFFTSize         = 512
+	diarMelBands        = 24
+	diarCepstra         = 13
+	diarMaxAutoSpeakers = 6
+	diarMinSilhouette   = 0.15
+	diarKMeansIters     = 30
+)
+
+// diarizeSegments assigns a speaker ID to each segment by clustering
+// per-segment MFCC statistics. speakers <= 0 estimates the count.
+func diarizeSegments(segments []models.Segment, samples []float32, sampleRate, speakers int) ([]models.Segment, map[string]string) {
+	if len(segments) == 0 {
+		return segments, nil
+	}
+
+	fb := newMelFilterbank(sampleRate)
+	features := make([][]float64, len(segments))
+	for i, seg := range segments {
+		features[i] = segmentEmbedding(samples, sampleRate, seg, fb)
+	}
+	standardize(features)
+
+	var labels []int
+	switch {
+	case speakers == 1 || len(segments) < 2:
+		labels = make([]int, len(segments))
+	case speakers > 1:
+		labels = kmeans(features, min(speakers, len(segments)))
+	default:
+		labels = make([]int, len(segments))
+		best := diarMinSilhouette
+		for k := 2; k <= diarMaxAutoSpeakers && k < len(segments); k++ {
+			candidate := kmeans(features, k)
+			if score := silhouette(features, candidate, k); score > best {
+				best = score
+				labels = candidate
+			}
+		}
+	}
+
+	// Number speakers in order of first appearance.
+	ids := make(map[int]string)
+	names := make(map[string]string)
+	for i, label := range labels {
+		id, ok := ids[label]
+		if !ok {
+			id = fmt.Sprintf("S%d", len(ids)+1)
+			ids[label] = id
+			names[id] = fmt.Sprintf("Speaker %d", len(ids))
+		}
+		segments[i].Speaker = id
+	}
+	return segments, names
+}
+
+func segmentEmbedding(samples []float32, sampleRate int, seg models.Segment, fb [][]float64) []float64 {
+	from := int(seg.Start * float64(sampleRate))
+	to := int(seg.End * float64(sampleRate))
+	if from < 0 {
+		from = 0
+	}
+	if to > len(samples) {
+		to = len(samples)
+	}
+
+	var frames [][]float64
+	for pos := from; pos+diarFrameLen <= to; pos += diarHopLen {
+		frames = append(frames, mfcc(samples[pos:pos+diarFrameLen], fb))
+	}
+
+	// Mean and standard deviation of each coefficient.
+	emb := make([]float64, 2*diarCepstra)
+	if len(frames) == 0 {
+		return emb
+	}
+	for _, f := range frames {
+		for c, v := range f {
+			emb[c] += v
+		}
+	}
+	for c := 0; c < diarCepstra; c++ {
+		emb[c] /= float64(len(frames))
+	}
+	for _, f := range frames {
+		for c, v := range f {
+			d := v - emb[c]
+			emb[diarCepstra+c] += d * d
+		}
+	}
+	for c := 0; c < diarCepstra; c++ {
+		emb[diarCepstra+c] = math.Sqrt(emb[diarCepstra+c] / float64(len(frames)))
+	}
+	return emb
+}
+
+func mfcc(frame []float32, fb [][]float64) []float64 {
+	buf := make([]complex128, diarFFTSize)
+	for i, s := range frame {
+		w := 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(len(frame)-1))
+		buf[i] = complex(float64(s)*w, 0)
+	}
+	fft(buf)
+
+	power := make([]float64, diarFFTSize/2+1)
+	for i := range power {
+		a := cmplx.Abs(buf[i])
+		power[i] = a * a
+	}
+
+	logMel := make([]float64, len(fb))
+	for b, weights := range fb {
+		var e float64
+		for i, w := range weights {
+			e += w * power[i]
+		}
+		logMel[b] = math.Log(e + 1e-10)
+	}
+
+	// DCT-II, skipping c0 which only carries loudness.
+	out := make([]float64, diarCepstra)
+	for c := range out {
+		var sum float64
+		for b, v := range logMel {
+			sum += v * math.Cos(math.Pi*float64(c+1)*(float64(b)+0.5)/float64(len(logMel)))
+		}
+		out[c] = sum
+	}
+	return out
+}
+
+func newMelFilterbank(sampleRate int) [][]float64 {
+	toMel := func(f float64) float64 { return 2595 * math.Log10(1+f/700) }
+	fromMel := func(m float64) float64 { return 700 * (math.Pow(10, m/2595) - 1) }
+
+	lo, hi := toMel(80), toMel(math.Min(7600, float64(sampleRate)/2))
+	bins := make([]int, diarMelBands+2)
+	for i := range bins {
+		f := fromMel(lo + (hi-lo)*float64(i)/float64(diarMelBands+1))
+		bins[i] = int(math.Floor(float64(diarFFTSize+1) * f / float64(sampleRate)))
+	}
+
+	fb := make([][]float64, diarMelBands)
+	for b := range fb {
+		fb[b] = make([]float64, diarFFTSize/2+1)
+		left, center, right := bins[b], bins[b+1], bins[b+2]
+		for i := left; i < center; i++ {
+			fb[b][i] = float64(i-left) / float64(center-left)
+		}
+		for i := center; i < right; i++ {
+			fb[b][i] = float64(right-i) / float64(right-center)
+		}
+	}
+	return fb
+}
+
+// fft is an in-place iterative radix-2 transform; len(buf) must be a power of two.
+func fft(buf []complex128) {
+	n := len(buf)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			buf[i], buf[j] = buf[j], buf[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := buf[start+k], buf[start+k+size/2]*w
+				buf[start+k], buf[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
+
+func standardize(features [][]float64) {
+	dims := len(features[0])
+	for d := 0; d < dims; d++ {
+		var mean, sq float64
+		for _, f := range features {
+			mean += f[d]
+		}
+		mean /= float64(len(features))
+		for _, f := range features {
+			sq += (f[d] - mean) * (f[d] - mean)
+		}
+		std := math.Sqrt(sq / float64(len(features)))
+		for _, f := range features {
+			if std > 0 {
+				f[d] = (f[d] - mean) / std
+			} else {
+				f[d] = 0
+			}
+		}
+	}
+}
+
+// kmeans clusters with farthest-point initialisation so results are
+// deterministic for the same input.
+func kmeans(features [][]float64, k int) []int {
+	centroids := [][]float64{append([]float64(nil), features[0]...)}
+	for len(centroids) < k {
+		far, farDist := 0, -1.0
+		for i, f := range features {
+			d := math.Inf(1)
+			for _, c := range centroids {
+				d = math.Min(d, sqDist(f, c))
+			}
+			if d > farDist {
+				far, farDist = i, d
+			}
+		}
+		centroids = append(centroids, append([]float64(nil), features[far]...))
+	}
+
+	labels := make([]int, len(features))
+	for iter := 0; iter < diarKMeansIters; iter++ {
+		changed := false
+		for i, f := range features {
+			best, bestDist := 0, math.Inf(1)
+			for c, centroid := range centroids {
+				if d := sqDist(f, centroid); d < bestDist {
+					best, bestDist = c, d
+				}
+			}
+			if labels[i] != best {
+				labels[i] = best
+				changed = true
+			}
+		}
+		if iter > 0 && !changed {
+			break
+		}
+
+		counts := make([]int, k)
+		for c := range centroids {
+			for d := range centroids[c] {
+				centroids[c][d] = 0
+			}
+		}
+		for i, f := range features {
+			counts[labels[i]]++
+			for d, v := range f {
+				centroids[labels[i]][d] += v
+			}
+		}
+		for c := range centroids {
+			if counts[c] == 0 {
+				continue
+			}
+			for d := range centroids[c] {
+				centroids[c][d] /= float64(counts[c])
+			}
+		}
+	}
+	return labels
+}
+
+func silhouette(features [][]float64, labels []int, k int) float64 {
+	var total float64
+	for i, f := range features {
+		sums := make([]float64, k)
+		counts := make([]int, k)
+		for j, g := range features {
+			if i == j {
+				continue
+			}
+			sums[labels[j]] += math.Sqrt(sqDist(f, g))
+			counts[labels[j]]++
+		}
+		if counts[labels[i]] == 0 {
+			continue
+		}
+		a := sums[labels[i]] / float64(counts[labels[i]])
+		b := math.Inf(1)
+		for c := 0; c < k; c++ {
+			if c != labels[i] && counts[c] > 0 {
+				b = math.Min(b, sums[c]/float64(counts[c]))
+			}
+		}
+		if math.IsInf(b, 1) {
+			continue
+		}
+		total += (b - a) / math.Max(a, b)
+	}
+	return total / float64(len(features))
+}
+
+func sqDist(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return sum
+}
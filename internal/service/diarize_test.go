@@ -0,0 +1,89 @@
+package service
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+// voice synthesises a harmonic source with the given pitch and a vowel-like
+// spectral envelope peaking at formant Hz, plus a little noise.
+func voice(samples []float32, from, to int, pitch, formant float64, rng *rand.Rand) {
+	for i := from; i < to; i++ {
+		t := float64(i) / testRate
+		var v float64
+		for h := 1; float64(h)*pitch < 7000; h++ {
+			f := float64(h) * pitch
+			gain := math.Exp(-math.Pow((f-formant)/400, 2))
+			v += gain * math.Sin(2*math.Pi*f*t)
+		}
+		samples[i] = float32(0.2*v + 0.002*rng.NormFloat64())
+	}
+}
+
+func TestDiarizeSegments(t *testing.T) {
+	type source struct{ pitch, formant float64 }
+	low, high := source{110, 700}, source{240, 2300}
+
+	tests := []struct {
+		name     string
+		sources  []source
+		speakers int
+		want     []string
+	}{
+		{
+			name:    "two sources estimated",
+			sources: []source{low, high, low, high, high, low},
+			want:    []string{"S1", "S2", "S1", "S2", "S2", "S1"},
+		},
+		{
+			name:     "two sources requested",
+			sources:  []source{high, low, high, low},
+			speakers: 2,
+			want:     []string{"S1", "S2", "S1", "S2"},
+		},
+		{
+			name:    "single source falls back to one speaker",
+			sources: []source{low, low, low, low, low, low},
+			want:    []string{"S1", "S1", "S1", "S1", "S1", "S1"},
+		},
+		{
+			name:     "one speaker requested",
+			sources:  []source{low, high, low},
+			speakers: 1,
+			want:     []string{"S1", "S1", "S1"},
+		},
+		{
+			name:    "single segment",
+			sources: []source{high},
+			want:    []string{"S1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			samples := make([]float32, len(tt.sources)*testRate)
+			segments := make([]models.Segment, len(tt.sources))
+			for i, src := range tt.sources {
+				voice(samples, i*testRate, (i+1)*testRate, src.pitch, src.formant, rng)
+				segments[i] = models.Segment{Index: i, Start: float64(i), End: float64(i + 1)}
+			}
+
+			got, names := diarizeSegments(segments, samples, testRate, tt.speakers)
+			var ids []string
+			for _, seg := range got {
+				ids = append(ids, seg.Speaker)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Fatalf("got %v, want %v", ids, tt.want)
+			}
+			distinct := len(slices.Compact(slices.Sorted(slices.Values(ids))))
+			if len(names) != distinct || names["S1"] != "Speaker 1" {
+				t.Errorf("names %v", names)
+			}
+		})
+	}
+}
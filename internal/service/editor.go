@@ -0,0 +1,277 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"whisper-transcriber/internal/infrastructure"
+	"whisper-transcriber/pkg/models"
+)
+
+// maxUndo bounds the stored history per transcript; each step is a full
+// snapshot of the transcript.
+const maxUndo = 100
+
+// Editor applies segment edits to library transcripts. Every edit stores the
+// previous version in <id>.history.json next to the transcript, so undo
+// survives restarts.
+type Editor struct {
+	mu        sync.Mutex
+	library   models.TranscriptLibrary
+	formatter models.Formatter
+	dir       string
+}
+
+func NewEditor(library models.TranscriptLibrary, formatter models.Formatter, dir string) *Editor {
+	return &Editor{library: library, formatter: formatter, dir: dir}
+}
+
+type editHistory struct {
+	Undo []*models.TranscriptionResult `json:"undo"`
+	Redo []*models.TranscriptionResult `json:"redo"`
+}
+
+func historyPath(dir, id string) string {
+	return filepath.Join(dir, id+".history.json")
+}
+
+func (e *Editor) EditText(id string, index int, text string) (*models.TranscriptionResult, error) {
+	return e.apply(id, func(r *models.TranscriptionResult) error {
+		if err := checkSegment(r, index); err != nil {
+			return err
+		}
+		r.Segments[index].Text = strings.TrimSpace(text)
+		return nil
+	})
+}
+
+// SplitSegment splits a segment before the rune at offset. The split time is
+// at when it falls inside the segment, otherwise it is placed in proportion
+// to the text on each side.
+func (e *Editor) SplitSegment(id string, index, offset int, at float64) (*models.TranscriptionResult, error) {
+	return e.apply(id, func(r *models.TranscriptionResult) error {
+		if err := checkSegment(r, index); err != nil {
+			return err
+		}
+		seg := r.Segments[index]
+		text := []rune(seg.Text)
+		if offset <= 0 || offset >= len(text) {
+			return fmt.Errorf("split offset %d is outside the segment text", offset)
+		}
+		if at <= seg.Start || at >= seg.End {
+			at = seg.Start + (seg.End-seg.Start)*float64(offset)/float64(len(text))
+		}
+
+		first, second := seg, seg
+		first.Text = strings.TrimSpace(string(text[:offset]))
+		first.End = at
+		second.Text = strings.TrimSpace(string(text[offset:]))
+		second.Start = at
+		second.Flags = append([]string(nil), seg.Flags...)
+
+		segs := make([]models.Segment, 0, len(r.Segments)+1)
+		segs = append(segs, r.Segments[:index]...)
+		segs = append(segs, first, second)
+		r.Segments = append(segs, r.Segments[index+1:]...)
+		return nil
+	})
+}
+
+// MergeSegments joins segments from..to inclusive into one. The merged
+// segment keeps the first segment's speaker and the lowest confidence.
+func (e *Editor) MergeSegments(id string, from, to int) (*models.TranscriptionResult, error) {
+	return e.apply(id, func(r *models.TranscriptionResult) error {
+		if err := checkSegment(r, from); err != nil {
+			return err
+		}
+		if err := checkSegment(r, to); err != nil {
+			return err
+		}
+		if to <= from {
+			return fmt.Errorf("nothing to merge")
+		}
+
+		merged := r.Segments[from]
+		texts := []string{strings.TrimSpace(merged.Text)}
+		flags := map[string]bool{}
+		for _, f := range merged.Flags {
+			flags[f] = true
+		}
+		for _, seg := range r.Segments[from+1 : to+1] {
+			texts = append(texts, strings.TrimSpace(seg.Text))
+			merged.End = seg.End
+			if seg.Confidence < merged.Confidence {
+				merged.Confidence = seg.Confidence
+			}
+			for _, f := range seg.Flags {
+				if !flags[f] {
+					flags[f] = true
+					merged.Flags = append(merged.Flags, f)
+				}
+			}
+		}
+		merged.Text = strings.Join(texts, " ")
+
+		segs := append([]models.Segment(nil), r.Segments[:from]...)
+		segs = append(segs, merged)
+		r.Segments = append(segs, r.Segments[to+1:]...)
+		return nil
+	})
+}
+
+// RenameSpeakers sets display names for speaker IDs. An empty name falls
+// back to the ID when rendering.
+func (e *Editor) RenameSpeakers(id string, names map[string]string) (*models.TranscriptionResult, error) {
+	return e.apply(id, func(r *models.TranscriptionResult) error {
+		for speaker := range names {
+			if _, ok := r.Speakers[speaker]; !ok {
+				return fmt.Errorf("unknown speaker: %s", speaker)
+			}
+		}
+		for speaker, name := range names {
+			r.Speakers[speaker] = strings.TrimSpace(name)
+		}
+		return nil
+	})
+}
+
+// ShiftSegments moves segments from..to inclusive by delta seconds; a
+// negative to means through the last segment. Times are clamped at zero.
+func (e *Editor) ShiftSegments(id string, from, to int, delta float64) (*models.TranscriptionResult, error) {
+	return e.apply(id, func(r *models.TranscriptionResult) error {
+		if to < 0 {
+			to = len(r.Segments) - 1
+		}
+		if err := checkSegment(r, from); err != nil {
+			return err
+		}
+		if err := checkSegment(r, to); err != nil {
+			return err
+		}
+		for i := from; i <= to; i++ {
+			r.Segments[i].Start = max(0, r.Segments[i].Start+delta)
+			r.Segments[i].End = max(0, r.Segments[i].End+delta)
+		}
+		return nil
+	})
+}
+
+func (e *Editor) Undo(id string) (*models.TranscriptionResult, error) {
+	return e.step(id, true)
+}
+
+func (e *Editor) Redo(id string) (*models.TranscriptionResult, error) {
+	return e.step(id, false)
+}
+
+// Export writes the transcript in format next to its original output, as
+// <name>.edited.<format> so neither the source nor the original output is
+// overwritten.
+func (e *Editor) Export(id, format string) (string, error) {
+	entry, err := e.library.Entry(id)
+	if err != nil {
+		return "", err
+	}
+	result, err := e.library.Get(id)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Dir(entry.SourcePath)
+	if entry.OutputPath != "" {
+		dir = filepath.Dir(entry.OutputPath)
+	}
+	name := filepath.Base(result.FilePath)
+	ext := filepath.Ext(name)
+	name = strings.TrimSuffix(name, ext) + ".edited" + ext
+	return e.formatter.WriteOutput(result, filepath.Join(dir, name), format)
+}
+
+// apply runs edit on the stored transcript and records the previous version
+// for undo. A new edit discards the redo steps.
+func (e *Editor) apply(id string, edit func(*models.TranscriptionResult) error) (*models.TranscriptionResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	before, err := e.library.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	after, err := e.library.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := edit(after); err != nil {
+		return nil, err
+	}
+	renumber(after)
+
+	history := e.loadHistory(id)
+	history.Undo = appendCapped(history.Undo, before)
+	history.Redo = nil
+	return e.commit(id, after, history)
+}
+
+func (e *Editor) step(id string, undo bool) (*models.TranscriptionResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	current, err := e.library.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	history := e.loadHistory(id)
+
+	from, to := &history.Undo, &history.Redo
+	if !undo {
+		from, to = to, from
+	}
+	if len(*from) == 0 {
+		if undo {
+			return nil, fmt.Errorf("nothing to undo")
+		}
+		return nil, fmt.Errorf("nothing to redo")
+	}
+	target := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = appendCapped(*to, current)
+	return e.commit(id, target, history)
+}
+
+func (e *Editor) commit(id string, result *models.TranscriptionResult, history editHistory) (*models.TranscriptionResult, error) {
+	if _, err := e.library.Update(id, result); err != nil {
+		return nil, err
+	}
+	if err := infrastructure.WriteJSON(historyPath(e.dir, id), history); err != nil {
+		return nil, fmt.Errorf("save edit history: %w", err)
+	}
+	return result, nil
+}
+
+func (e *Editor) loadHistory(id string) editHistory {
+	var history editHistory
+	_ = infrastructure.ReadJSON(historyPath(e.dir, id), &history)
+	return history
+}
+
+func appendCapped(stack []*models.TranscriptionResult, r *models.TranscriptionResult) []*models.TranscriptionResult {
+	stack = append(stack, r)
+	if len(stack) > maxUndo {
+		stack = stack[len(stack)-maxUndo:]
+	}
+	return stack
+}
+
+func checkSegment(r *models.TranscriptionResult, index int) error {
+	if index < 0 || index >= len(r.Segments) {
+		return fmt.Errorf("segment %d out of range", index)
+	}
+	return nil
+}
+
+func renumber(r *models.TranscriptionResult) {
+	for i := range r.Segments {
+		r.Segments[i].Index = i
+	}
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func newTestEditor(t *testing.T) (*Editor, string) {
+	t.Helper()
+	dir := t.TempDir()
+	library := NewLibrary(filepath.Join(dir, "library"))
+	entry, err := library.Add(&models.TranscriptionResult{
+		FilePath: filepath.Join(dir, "talk.mp4"),
+		Segments: []models.Segment{
+			{Start: 0, End: 4, Text: "hello world", Confidence: 0.9, Speaker: "S1"},
+			{Index: 1, Start: 4, End: 6, Text: "second", Confidence: 0.5, Flags: []string{"loop"}},
+			{Index: 2, Start: 6, End: 9, Text: "third", Confidence: 0.7, Flags: []string{"loop", "known_phrase"}},
+		},
+		Speakers: map[string]string{"S1": "", "S2": ""},
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewEditor(library, NewFormatter(), filepath.Join(dir, "library")), entry.ID
+}
+
+func segmentTexts(r *models.TranscriptionResult) []string {
+	var texts []string
+	for i, seg := range r.Segments {
+		if seg.Index != i {
+			texts = append(texts, "bad index")
+		}
+		texts = append(texts, seg.Text)
+	}
+	return texts
+}
+
+func TestEditorSplitSegment(t *testing.T) {
+	tests := []struct {
+		name      string
+		offset    int
+		at        float64
+		wantTexts []string
+		wantSplit float64
+		wantErr   bool
+	}{
+		{name: "at time", offset: 5, at: 1.5, wantTexts: []string{"hello", "world", "second", "third"}, wantSplit: 1.5},
+		{name: "proportional", offset: 6, at: -1, wantTexts: []string{"hello", "world", "second", "third"}, wantSplit: 4 * 6.0 / 11},
+		{name: "offset at start", offset: 0, wantErr: true},
+		{name: "offset past end", offset: 11, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			editor, id := newTestEditor(t)
+			got, err := editor.SplitSegment(id, 0, tt.offset, tt.at)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if texts := segmentTexts(got); !slices.Equal(texts, tt.wantTexts) {
+				t.Fatalf("got %q, want %q", texts, tt.wantTexts)
+			}
+			first, second := got.Segments[0], got.Segments[1]
+			if first.End != tt.wantSplit || second.Start != tt.wantSplit || first.Start != 0 || second.End != 4 {
+				t.Errorf("split at %v-%v / %v-%v, want %v", first.Start, first.End, second.Start, second.End, tt.wantSplit)
+			}
+			if second.Speaker != "S1" {
+				t.Errorf("second half lost the speaker: %+v", second)
+			}
+		})
+	}
+}
+
+func TestEditorMergeSegments(t *testing.T) {
+	tests := []struct {
+		name      string
+		from, to  int
+		wantTexts []string
+		wantErr   bool
+	}{
+		{name: "two", from: 1, to: 2, wantTexts: []string{"hello world", "second third"}},
+		{name: "all", from: 0, to: 2, wantTexts: []string{"hello world second third"}},
+		{name: "nothing", from: 1, to: 1, wantErr: true},
+		{name: "out of range", from: 1, to: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			editor, id := newTestEditor(t)
+			got, err := editor.MergeSegments(id, tt.from, tt.to)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if texts := segmentTexts(got); !slices.Equal(texts, tt.wantTexts) {
+				t.Fatalf("got %q, want %q", texts, tt.wantTexts)
+			}
+			merged := got.Segments[len(got.Segments)-1]
+			if merged.End != 9 {
+				t.Errorf("merged end %v, want 9", merged.End)
+			}
+			if merged.Confidence != 0.5 {
+				t.Errorf("merged confidence %v, want the lowest", merged.Confidence)
+			}
+			if !slices.Equal(merged.Flags, []string{"loop", "known_phrase"}) {
+				t.Errorf("merged flags %v", merged.Flags)
+			}
+		})
+	}
+}
+
+func TestEditorUndoRedo(t *testing.T) {
+	editor, id := newTestEditor(t)
+	if _, err := editor.Undo(id); err == nil {
+		t.Fatal("undo with no history should fail")
+	}
+	if _, err := editor.EditText(id, 0, "  hi  "); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := editor.MergeSegments(id, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// History is stored on disk, so a new editor can undo the edits.
+	editor = NewEditor(editor.library, editor.formatter, editor.dir)
+	steps := []struct {
+		undo bool
+		want []string
+	}{
+		{undo: true, want: []string{"hi", "second", "third"}},
+		{undo: true, want: []string{"hello world", "second", "third"}},
+		{undo: false, want: []string{"hi", "second", "third"}},
+		{undo: false, want: []string{"hi", "second third"}},
+	}
+	for i, step := range steps {
+		var got *models.TranscriptionResult
+		var err error
+		if step.undo {
+			got, err = editor.Undo(id)
+		} else {
+			got, err = editor.Redo(id)
+		}
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		if texts := segmentTexts(got); !slices.Equal(texts, step.want) {
+			t.Fatalf("step %d: got %q, want %q", i, texts, step.want)
+		}
+	}
+	if _, err := editor.Redo(id); err == nil {
+		t.Fatal("redo past the last edit should fail")
+	}
+
+	// A new edit discards the redo steps.
+	if _, err := editor.Undo(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := editor.EditText(id, 0, "hey"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := editor.Redo(id); err == nil {
+		t.Fatal("redo after a new edit should fail")
+	}
+}
+
+func TestEditorRenameSpeakers(t *testing.T) {
+	editor, id := newTestEditor(t)
+	if _, err := editor.RenameSpeakers(id, map[string]string{"S9": "Nobody"}); err == nil {
+		t.Fatal("want error for an unknown speaker")
+	}
+	if _, err := editor.RenameSpeakers(id, map[string]string{"S1": " Alice "}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := editor.library.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Speakers["S1"] != "Alice" {
+		t.Errorf("speakers %v", got.Speakers)
+	}
+}
+
+func TestEditorExportKeepsOriginal(t *testing.T) {
+	editor, id := newTestEditor(t)
+	entry, err := editor.library.Entry(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := filepath.Join(filepath.Dir(entry.SourcePath), "talk.srt")
+	if err := os.WriteFile(original, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := editor.Export(id, "srt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(filepath.Dir(entry.SourcePath), "talk.edited.srt"); path != want {
+		t.Errorf("exported to %s, want %s", path, want)
+	}
+	if data, _ := os.ReadFile(original); string(data) != "original" {
+		t.Errorf("original output was overwritten")
+	}
+}
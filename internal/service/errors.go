@@ -0,0 +1,52 @@
+package service
+
+import (
+	"strings"
+
+	"whisper-transcriber/internal/infrastructure"
+	"whisper-transcriber/pkg/models"
+)
+
+// toolFailures maps ffmpeg and ffprobe messages to error codes. The first
+// match wins, so more specific markers come first.
+var toolFailures = []struct {
+	marker  string
+	code    models.ErrorCode
+	message string
+}{
+	{"No space left on device", models.CodeDiskFull, "disk is full"},
+	{"Disk quota exceeded", models.CodeDiskFull, "disk is full"},
+	{"Cannot allocate memory", models.CodeOutOfMemory, "out of memory"},
+	{"matches no streams", models.CodeNoAudioStream, "the selected audio stream does not exist"},
+	{"does not contain any stream", models.CodeNoAudioStream, "file has no audio stream"},
+	{"Output file does not contain any stream", models.CodeNoAudioStream, "file has no audio stream"},
+	{"Invalid data found when processing input", models.CodeUnsupportedMedia, "unsupported or damaged media"},
+	{"could not find codec parameters", models.CodeUnsupportedMedia, "unsupported or damaged media"},
+	{"Decoder not found", models.CodeUnsupportedMedia, "no decoder for this media"},
+	{"Unknown decoder", models.CodeUnsupportedMedia, "no decoder for this media"},
+	{"Connection reset", models.CodeNetwork, "network input failed"},
+	{"Connection timed out", models.CodeNetwork, "network input failed"},
+	{"Connection refused", models.CodeNetwork, "network input failed"},
+	{"Network is unreachable", models.CodeNetwork, "network input failed"},
+}
+
+// toolError wraps a failed ffmpeg or ffprobe run, keeping its stderr as the
+// details. Output that matches no known failure gets code and message.
+func toolError(err error, stderr string, code models.ErrorCode, message string) *models.AppError {
+	appErr := &models.AppError{Code: code, Message: message, Details: strings.TrimSpace(stderr), Err: err}
+	for _, f := range toolFailures {
+		if strings.Contains(stderr, f.marker) {
+			appErr.Code, appErr.Message = f.code, f.message
+			break
+		}
+	}
+	return appErr
+}
+
+// diskError marks a failed write as a full disk when it is one.
+func diskError(err error) error {
+	if err != nil && infrastructure.IsDiskFull(err) {
+		return models.NewError(models.CodeDiskFull, "disk is full", err)
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestToolError(t *testing.T) {
+	tests := []struct {
+		name     string
+		stderr   string
+		wantCode models.ErrorCode
+		wantMsg  string
+	}{
+		{name: "unrecognised output", stderr: "something odd happened\n", wantCode: models.CodeUnknown, wantMsg: "ffmpeg failed"},
+		{name: "disk full", stderr: "out.wav: No space left on device", wantCode: models.CodeDiskFull, wantMsg: "disk is full"},
+		{name: "quota", stderr: "Disk quota exceeded", wantCode: models.CodeDiskFull, wantMsg: "disk is full"},
+		{name: "memory", stderr: "Cannot allocate memory", wantCode: models.CodeOutOfMemory, wantMsg: "out of memory"},
+		{name: "missing stream", stderr: "Stream map '0:a:3' matches no streams.", wantCode: models.CodeNoAudioStream, wantMsg: "the selected audio stream does not exist"},
+		{name: "no streams", stderr: "Output file #0 does not contain any stream", wantCode: models.CodeNoAudioStream, wantMsg: "file has no audio stream"},
+		{name: "damaged", stderr: "in.mp4: Invalid data found when processing input", wantCode: models.CodeUnsupportedMedia, wantMsg: "unsupported or damaged media"},
+		{name: "codec parameters", stderr: "could not find codec parameters for stream 1", wantCode: models.CodeUnsupportedMedia, wantMsg: "unsupported or damaged media"},
+		{name: "decoder", stderr: "Decoder not found", wantCode: models.CodeUnsupportedMedia, wantMsg: "no decoder for this media"},
+		{name: "unknown decoder", stderr: "Unknown decoder 'foo'", wantCode: models.CodeUnsupportedMedia, wantMsg: "no decoder for this media"},
+		{name: "network", stderr: "http://host/a.mp3: Connection refused", wantCode: models.CodeNetwork, wantMsg: "network input failed"},
+		{name: "first match wins", stderr: "Invalid data found when processing input\nNo space left on device", wantCode: models.CodeDiskFull, wantMsg: "disk is full"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cause := errors.New("exit status 1")
+			err := toolError(cause, tt.stderr, models.CodeUnknown, "ffmpeg failed")
+			if err.Code != tt.wantCode || err.Message != tt.wantMsg {
+				t.Errorf("got %s %q, want %s %q", err.Code, err.Message, tt.wantCode, tt.wantMsg)
+			}
+			if err.Details != strings.TrimSpace(tt.stderr) || !errors.Is(err, cause) {
+				t.Errorf("details %q, cause %v", err.Details, err.Err)
+			}
+		})
+	}
+}
+
+func TestDiskError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("disk-full errors are Windows error codes there")
+	}
+	full := fmt.Errorf("write output: %w", &os.PathError{Op: "write", Path: "out.srt", Err: syscall.ENOSPC})
+	if info := models.DescribeError(diskError(full)); info.Code != models.CodeDiskFull {
+		t.Errorf("disk full: %+v", info)
+	}
+	other := errors.New("permission denied")
+	if err := diskError(other); err != other {
+		t.Errorf("other errors changed: %v", err)
+	}
+	if diskError(nil) != nil {
+		t.Error("nil changed")
+	}
+}
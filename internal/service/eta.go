@@ -0,0 +1,93 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"whisper-transcriber/pkg/models"
+)
+
+// minETAProgress is the transcription progress, in percent, after which a
+// file's observed rate replaces its estimate.
+const minETAProgress = 5
+
+// etaTracker times the files of a batch run. Before transcription has made
+// progress a file's remaining time comes from the queue's estimate, which is
+// based on the probed duration and the recorded throughput.
+type etaTracker struct {
+	mu      sync.Mutex
+	queue   models.FileQueue
+	workers int
+	files   map[string]*fileClock
+}
+
+type fileClock struct {
+	start     time.Time
+	procStart time.Time
+	procFrom  int
+}
+
+func newETATracker(queue models.FileQueue, workers int) *etaTracker {
+	return &etaTracker{queue: queue, workers: workers, files: make(map[string]*fileClock)}
+}
+
+// timing records a status update and returns the timing to report with it.
+// The queue must already reflect the update.
+func (t *etaTracker) timing(fileID, status string, progress int) models.FileTiming {
+	now := time.Now()
+	items := t.queue.Snapshot()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var timing models.FileTiming
+	clock := t.files[fileID]
+	switch status {
+	case "extracting", "processing", "retrying":
+		if clock == nil {
+			clock = &fileClock{start: now}
+			t.files[fileID] = clock
+		}
+		if status == "processing" && clock.procStart.IsZero() {
+			clock.procStart = now
+			clock.procFrom = progress
+		}
+		for _, item := range items {
+			if item.ID == fileID {
+				timing.ETASec = clock.remaining(item.EstimatedSec, progress, now)
+				break
+			}
+		}
+	default:
+		delete(t.files, fileID)
+	}
+	if clock != nil {
+		timing.ElapsedSec = now.Sub(clock.start).Seconds()
+	}
+	timing.BatchETASec = t.batchETA(items, now)
+	return timing
+}
+
+// batchETA spreads the remaining work over the workers, but never reports
+// less than the longest running file has left.
+func (t *etaTracker) batchETA(items []models.FileItem, now time.Time) float64 {
+	var total, longest float64
+	for _, item := range items {
+		if clock, ok := t.files[item.ID]; ok {
+			eta := clock.remaining(item.EstimatedSec, item.Progress, now)
+			total += eta
+			longest = max(longest, eta)
+		} else if isRunnable(item.Status) {
+			total += item.EstimatedSec
+		}
+	}
+	return max(total/float64(t.workers), longest)
+}
+
+func (c *fileClock) remaining(estimate float64, progress int, now time.Time) float64 {
+	if !c.procStart.IsZero() && progress-c.procFrom >= minETAProgress {
+		perPercent := now.Sub(c.procStart).Seconds() / float64(progress-c.procFrom)
+		return perPercent * float64(100-progress)
+	}
+	return max(estimate-now.Sub(c.start).Seconds(), 0)
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestFileClockRemaining(t *testing.T) {
+	now := time.Now()
+	ago := func(sec float64) time.Time { return now.Add(-time.Duration(sec * float64(time.Second))) }
+	tests := []struct {
+		name     string
+		clock    fileClock
+		estimate float64
+		progress int
+		want     float64
+	}{
+		{name: "estimate before processing", clock: fileClock{start: ago(10)}, estimate: 60, want: 50},
+		{name: "estimate never negative", clock: fileClock{start: ago(90)}, estimate: 60, want: 0},
+		{
+			name:     "estimate while progress is too small",
+			clock:    fileClock{start: ago(20), procStart: ago(5), procFrom: 10},
+			estimate: 60, progress: 14, want: 40,
+		},
+		{
+			name:     "observed rate",
+			clock:    fileClock{start: ago(30), procStart: ago(20)},
+			estimate: 60, progress: 25, want: 60,
+		},
+		{
+			name:     "rate measured from where processing started",
+			clock:    fileClock{start: ago(30), procStart: ago(10), procFrom: 50},
+			estimate: 600, progress: 60, want: 40,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.clock.remaining(tt.estimate, tt.progress, now); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestETATrackerBatch(t *testing.T) {
+	q := newTestQueue(
+		models.FileItem{ID: "a", Status: "pending", EstimatedSec: 30},
+		models.FileItem{ID: "b", Status: "pending", EstimatedSec: 40},
+		models.FileItem{ID: "c", Status: "interrupted", EstimatedSec: 50},
+		models.FileItem{ID: "d", Status: "done", EstimatedSec: 500},
+		models.FileItem{ID: "e", Status: "error", EstimatedSec: 500},
+	)
+	tracker := newETATracker(q, 2)
+
+	q.UpdateStatus("a", "extracting", 0, nil)
+	timing := tracker.timing("a", "extracting", 0)
+	if math.Abs(timing.ETASec-30) > 1 {
+		t.Errorf("file ETA %v, want the estimate", timing.ETASec)
+	}
+	if math.Abs(timing.BatchETASec-60) > 1 {
+		t.Errorf("batch ETA %v, want the runnable estimates over 2 workers", timing.BatchETASec)
+	}
+
+	// A long file bounds the batch from below.
+	q.UpdateStatus("b", "done", 100, nil)
+	tracker.timing("b", "done", 100)
+	q.UpdateStatus("c", "done", 100, nil)
+	timing = tracker.timing("c", "done", 100)
+	if timing.ETASec != 0 || timing.ElapsedSec != 0 {
+		t.Errorf("finished file timing %+v", timing)
+	}
+	if math.Abs(timing.BatchETASec-30) > 1 {
+		t.Errorf("batch ETA %v, want the running file's", timing.BatchETASec)
+	}
+
+	// Finishing forgets the file.
+	q.UpdateStatus("a", "done", 100, nil)
+	if timing = tracker.timing("a", "done", 100); timing.BatchETASec != 0 {
+		t.Errorf("batch ETA %v after the last file", timing.BatchETASec)
+	}
+	if len(tracker.files) != 0 {
+		t.Errorf("clocks left: %v", tracker.files)
+	}
+}
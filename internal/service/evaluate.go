@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"whisper-transcriber/internal/infrastructure"
+	"whisper-transcriber/pkg/models"
+)
+
+// referenceExtensions are tried in order when pairing media with its
+// reference transcript.
+var referenceExtensions = []string{".txt", ".srt", ".vtt", ".ass", ".json"}
+
+// Evaluator scores transcriptions of a dataset against reference
+// transcripts. Files are processed one at a time so timings are not skewed
+// by concurrent work.
+type Evaluator struct {
+	transcriber models.Transcriber
+	ffmpeg      models.FFmpegService
+	reportDir   string
+}
+
+func NewEvaluator(transcriber models.Transcriber, ffmpeg models.FFmpegService, reportDir string) *Evaluator {
+	return &Evaluator{transcriber: transcriber, ffmpeg: ffmpeg, reportDir: reportDir}
+}
+
+// Run evaluates every media file in cfg.Dir that has a reference and writes
+// JSON and CSV reports. The transcriber must already be loaded. A file that
+// fails is reported with its error and left out of the totals.
+func (e *Evaluator) Run(ctx context.Context, cfg models.EvalConfig, onProgress func(done, total int, path string)) (*models.EvalReport, error) {
+	filters, err := PreprocessFilters(cfg.Transcription.Preprocess)
+	if err != nil {
+		return nil, err
+	}
+	media, err := ScanFolder(ctx, cfg.Dir, models.ScanOptions{Recursive: cfg.Recursive})
+	if err != nil {
+		return nil, fmt.Errorf("scan %s: %w", cfg.Dir, err)
+	}
+	var pairs [][2]string
+	for _, path := range media {
+		if ref := findReference(path); ref != "" {
+			pairs = append(pairs, [2]string{path, ref})
+		}
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("no media files with reference transcripts in %s", cfg.Dir)
+	}
+
+	ffmpegThreads, whisperThreads := threadBudget(1, cfg.Transcription.Concurrency.MaxThreads)
+	report := &models.EvalReport{
+		StartedAt: time.Now(),
+		Model:     filepath.Base(e.transcriber.ModelPath()),
+		Config:    cfg.Transcription,
+		Filters:   filters,
+		Threads:   whisperThreads,
+		Normalize: cfg.Normalize,
+	}
+
+	var errs, refWords, charErrs, refChars int
+	for i, pair := range pairs {
+		if onProgress != nil {
+			onProgress(i, len(pairs), pair[0])
+		}
+		res := models.EvalFileResult{Path: pair[0], Reference: pair[1]}
+		if err := e.evaluateFile(ctx, &res, cfg, filters, ffmpegThreads, whisperThreads); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			slog.Warn("evaluation failed", "file", pair[0], "err", err)
+			res.Error = err.Error()
+		} else {
+			slog.Info("evaluated", "file", pair[0], "wer", res.WER, "cer", res.CER, "rtf", res.RTF)
+			errs += res.Substitutions + res.Deletions + res.Insertions
+			refWords += res.RefWords
+			charErrs += res.CharErrors
+			refChars += res.RefChars
+			report.AudioSec += res.AudioSec
+			report.ProcessSec += res.ExtractSec + res.TranscribeSec
+		}
+		report.Files = append(report.Files, res)
+	}
+	if onProgress != nil {
+		onProgress(len(pairs), len(pairs), "")
+	}
+
+	report.WER = ratio(errs, refWords)
+	report.CER = ratio(charErrs, refChars)
+	if report.AudioSec > 0 {
+		report.RTF = report.ProcessSec / report.AudioSec
+	}
+
+	dir := cfg.ReportDir
+	if dir == "" {
+		dir = e.reportDir
+	}
+	if err := writeEvalReports(report, dir); err != nil {
+		return report, fmt.Errorf("write report: %w", err)
+	}
+	return report, nil
+}
+
+func (e *Evaluator) evaluateFile(
+	ctx context.Context,
+	res *models.EvalFileResult,
+	cfg models.EvalConfig,
+	filters []string,
+	ffmpegThreads, whisperThreads int,
+) error {
+	reference, err := readReference(res.Reference)
+	if err != nil {
+		return fmt.Errorf("reference: %w", err)
+	}
+
+	start := time.Now()
+	wavPath, err := e.ffmpeg.ExtractAudio(ctx, res.Path, models.ExtractOptions{
+		StreamIndex: models.DefaultStream,
+		Filters:     filters,
+		Threads:     ffmpegThreads,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(wavPath)
+	res.ExtractSec = time.Since(start).Seconds()
+	res.AudioSec = wavDuration(wavPath)
+
+	start = time.Now()
+	result, err := e.transcriber.TranscribeFile(ctx, "", wavPath, models.TranscribeOptions{
+		Language:    cfg.Transcription.Language,
+		VAD:         cfg.Transcription.VAD,
+		Cleanup:     cfg.Transcription.Cleanup,
+		Diarization: cfg.Transcription.Diarization,
+		TrimSilence: trimsSilence(cfg.Transcription.Preprocess),
+		Threads:     whisperThreads,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	res.TranscribeSec = time.Since(start).Seconds()
+	if res.AudioSec > 0 {
+		res.RTF = (res.ExtractSec + res.TranscribeSec) / res.AudioSec
+	}
+
+	scoreText(res, reference, resultText(result), cfg.Normalize)
+	return nil
+}
+
+func findReference(mediaPath string) string {
+	base := strings.TrimSuffix(mediaPath, filepath.Ext(mediaPath))
+	for _, ext := range referenceExtensions {
+		if info, err := os.Stat(base + ext); err == nil && !info.IsDir() {
+			return base + ext
+		}
+	}
+	return ""
+}
+
+// readReference returns the reference text; plain text files are used as
+// they are, subtitle formats are parsed and their cues joined.
+func readReference(path string) (string, error) {
+	if strings.EqualFold(filepath.Ext(path), ".txt") {
+		data, err := os.ReadFile(path)
+		return string(data), err
+	}
+	result, err := ParseTranscript(path)
+	if err != nil {
+		return "", err
+	}
+	return resultText(result), nil
+}
+
+func resultText(result *models.TranscriptionResult) string {
+	texts := make([]string, len(result.Segments))
+	for i, seg := range result.Segments {
+		texts[i] = seg.Text
+	}
+	return strings.Join(texts, " ")
+}
+
+func writeEvalReports(report *models.EvalReport, dir string) error {
+	base := filepath.Join(dir, "eval-"+report.StartedAt.Format("20060102-150405"))
+	jsonPath, csvPath := base+".json", base+".csv"
+	report.ReportPaths = []string{jsonPath, csvPath}
+
+	if err := infrastructure.WriteJSON(jsonPath, report); err != nil {
+		return err
+	}
+
+	f, err := os.Create(csvPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{
+		"path", "reference", "ref_words", "substitutions", "deletions", "insertions", "wer",
+		"ref_chars", "char_errors", "cer", "audio_sec", "extract_sec", "transcribe_sec", "rtf", "error",
+	})
+	for _, r := range report.Files {
+		w.Write([]string{
+			r.Path, r.Reference,
+			strconv.Itoa(r.RefWords), strconv.Itoa(r.Substitutions), strconv.Itoa(r.Deletions), strconv.Itoa(r.Insertions), formatFloat(r.WER),
+			strconv.Itoa(r.RefChars), strconv.Itoa(r.CharErrors), formatFloat(r.CER),
+			formatFloat(r.AudioSec), formatFloat(r.ExtractSec), formatFloat(r.TranscribeSec), formatFloat(r.RTF),
+			r.Error,
+		})
+	}
+	w.Write([]string{
+		"TOTAL", report.Model, "", "", "", "", formatFloat(report.WER),
+		"", "", formatFloat(report.CER), formatFloat(report.AudioSec), "", formatFloat(report.ProcessSec), formatFloat(report.RTF), "",
+	})
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
@@ -2,13 +2,17 @@ package service
 
 import (
 	"archive/zip"
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"whisper-transcriber/pkg/models"
@@ -61,7 +65,7 @@ func (s *FFmpegSvc) Download(ctx context.Context, onProgress models.ProgressFunc
 
 	resp, err := infrastructure.HTTPGetWithRetry(ctx, ffmpegWinURL, 3)
 	if err != nil {
-		return fmt.Errorf("download request failed: %w", err)
+		return models.NewError(models.CodeNetwork, "download failed; check your internet connection", err)
 	}
 	defer resp.Body.Close()
 
@@ -87,7 +91,7 @@ func (s *FFmpegSvc) Download(ctx context.Context, onProgress models.ProgressFunc
 		n, readErr := resp.Body.Read(buf)
 		if n > 0 {
 			if _, writeErr := out.Write(buf[:n]); writeErr != nil {
-				return writeErr
+				return diskError(writeErr)
 			}
 			downloaded += int64(n)
 			if total > 0 && onProgress != nil {
@@ -101,47 +105,172 @@ func (s *FFmpegSvc) Download(ctx context.Context, onProgress models.ProgressFunc
 			break
 		}
 		if readErr != nil {
-			return readErr
+			return models.NewError(models.CodeNetwork, "download interrupted", readErr)
 		}
 	}
 	out.Close()
 
-	return extractFFmpegFromZip(tmpZip, dest)
+	if err := extractBinaryFromZip(tmpZip, "bin/ffmpeg.exe", dest); err != nil {
+		return err
+	}
+	return extractBinaryFromZip(tmpZip, "bin/ffprobe.exe", filepath.Join(s.appDir, "ffprobe.exe"))
 }
 
-func (s *FFmpegSvc) ExtractAudio(ctx context.Context, inputPath string) (string, error) {
+func (s *FFmpegSvc) ExtractAudio(ctx context.Context, inputPath string, opts models.ExtractOptions, onProgress models.ProgressFunc) (string, error) {
 	ff, err := s.binPath()
 	if err != nil {
 		return "", err
 	}
 
+	duration := selectedDuration(opts.Ranges, opts.Duration)
+
 	tmpFile, err := os.CreateTemp("", "whisper-*.wav")
 	if err != nil {
-		return "", err
+		return "", diskError(err)
 	}
 	outPath := tmpFile.Name()
 	tmpFile.Close()
 
-	cmd := exec.CommandContext(ctx, ff,
-		"-i", inputPath,
+	var filters []string
+	var args []string
+	switch {
+	case len(opts.Ranges) == 1:
+		// A single range can use input seeking, which skips decoding the rest.
+		r := opts.Ranges[0]
+		args = append(args, "-ss", formatSeconds(r.Start))
+		if r.End > 0 {
+			args = append(args, "-to", formatSeconds(r.End))
+		}
+	case len(opts.Ranges) > 1:
+		filters = append(filters, rangeSelectFilter(opts.Ranges), "asetpts=N/SR/TB")
+	}
+	filters = append(filters, opts.Filters...)
+
+	if opts.Threads > 0 {
+		// Before -i this limits decoder threads, which dominate extraction.
+		args = append(args, "-threads", strconv.Itoa(opts.Threads))
+	}
+	args = append(args, "-i", inputPath)
+	if opts.StreamIndex != models.DefaultStream {
+		args = append(args, "-map", fmt.Sprintf("0:%d", opts.StreamIndex))
+	}
+	if len(filters) > 0 {
+		args = append(args, "-af", strings.Join(filters, ","))
+	}
+	args = append(args,
 		"-ar", "16000",
 		"-ac", "1",
 		"-c:a", "pcm_s16le",
+		"-progress", "pipe:1",
+		"-nostats",
 		"-y",
 		outPath,
 	)
-	output, err := cmd.CombinedOutput()
+
+	cmd := exec.CommandContext(ctx, ff, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
+		os.Remove(outPath)
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		os.Remove(outPath)
+		return "", fmt.Errorf("ffmpeg failed to start: %w", err)
+	}
+
+	readFFmpegProgress(stdout, duration, onProgress)
+
+	if err := cmd.Wait(); err != nil {
 		os.Remove(outPath)
 		if ctx.Err() != nil {
 			return "", fmt.Errorf("ffmpeg cancelled: %w", ctx.Err())
 		}
-		return "", fmt.Errorf("ffmpeg failed: %s\n%s", err, string(output))
+		slog.Warn("ffmpeg failed", "input", inputPath, "err", err, "stderr", stderr.String())
+		failure := toolError(err, stderr.String(), models.CodeUnknown, "ffmpeg failed")
+		if isTransientFailure(stderr.String()) {
+			return "", &models.TransientError{Err: failure}
+		}
+		return "", failure
 	}
+	slog.Debug("ffmpeg finished", "input", inputPath, "args", args, "stderr", stderr.String())
 	return outPath, nil
 }
 
-func extractFFmpegFromZip(zipPath, destPath string) error {
+var transientMarkers = []string{
+	"Resource temporarily unavailable",
+	"Device or resource busy",
+	"Input/output error",
+	"Connection reset",
+	"Connection timed out",
+	"Connection refused",
+	"Network is unreachable",
+	"Operation timed out",
+	"Cannot allocate memory",
+}
+
+func isTransientFailure(stderr string) bool {
+	for _, marker := range transientMarkers {
+		if strings.Contains(stderr, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatSeconds(sec float64) string {
+	return strconv.FormatFloat(sec, 'f', 3, 64)
+}
+
+func rangeSelectFilter(ranges []models.TimeRange) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		if r.End > 0 {
+			parts[i] = fmt.Sprintf("between(t,%s,%s)", formatSeconds(r.Start), formatSeconds(r.End))
+		} else {
+			parts[i] = fmt.Sprintf("gte(t,%s)", formatSeconds(r.Start))
+		}
+	}
+	return fmt.Sprintf("aselect='%s'", strings.Join(parts, "+"))
+}
+
+func readFFmpegProgress(r io.Reader, duration float64, onProgress models.ProgressFunc) {
+	lastPct := -1
+	report := func(pct int) {
+		if pct > 100 {
+			pct = 100
+		}
+		if onProgress == nil || pct == lastPct {
+			return
+		}
+		lastPct = pct
+		onProgress(pct, "", "")
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "out_time_us", "out_time_ms":
+			// Both keys carry microseconds; out_time_ms is a historical misnomer.
+			us, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || duration <= 0 || us < 0 {
+				continue
+			}
+			report(int(float64(us) / 1e6 / duration * 100))
+		case "progress":
+			if value == "end" {
+				report(100)
+			}
+		}
+	}
+}
+
+func extractBinaryFromZip(zipPath, suffix, destPath string) error {
 	r, err := zip.OpenReader(zipPath)
 	if err != nil {
 		return err
@@ -149,7 +278,7 @@ func extractFFmpegFromZip(zipPath, destPath string) error {
 	defer r.Close()
 
 	for _, f := range r.File {
-		if strings.HasSuffix(f.Name, "bin/ffmpeg.exe") {
+		if strings.HasSuffix(f.Name, suffix) {
 			rc, err := f.Open()
 			if err != nil {
 				return err
@@ -166,5 +295,5 @@ func extractFFmpegFromZip(zipPath, destPath string) error {
 			return err
 		}
 	}
-	return fmt.Errorf("ffmpeg.exe not found in archive")
+	return fmt.Errorf("%s not found in archive", filepath.Base(suffix))
 }
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadFFmpegProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		duration float64
+		want     []int
+	}{
+		{
+			name:     "microseconds",
+			output:   "out_time_us=2500000\nprogress=continue\nout_time_us=5000000\nprogress=continue\n",
+			duration: 10,
+			want:     []int{25, 50},
+		},
+		{
+			name:     "out_time_ms carries microseconds",
+			output:   "out_time_ms=7500000\n",
+			duration: 10,
+			want:     []int{75},
+		},
+		{
+			name:     "repeated percentages reported once",
+			output:   "out_time_us=1000000\nout_time_us=1000001\nout_time_us=2000000\n",
+			duration: 10,
+			want:     []int{10, 20},
+		},
+		{
+			name:     "end reports 100",
+			output:   "out_time_us=9000000\nprogress=end\n",
+			duration: 10,
+			want:     []int{90, 100},
+		},
+		{
+			name:     "capped at 100",
+			output:   "out_time_us=12000000\nprogress=end\n",
+			duration: 10,
+			want:     []int{100},
+		},
+		{
+			name:     "unknown duration only reports the end",
+			output:   "out_time_us=5000000\nprogress=end\n",
+			duration: 0,
+			want:     []int{100},
+		},
+		{
+			name:     "ignores other keys and bad values",
+			output:   "frame=10\nout_time_us=N/A\nout_time_us=-1\nspeed=1.2x\nnot a pair\n",
+			duration: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			readFFmpegProgress(strings.NewReader(tt.output), tt.duration, func(pct int, _, _ string) {
+				got = append(got, pct)
+			})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,51 +1,219 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
 
+	"whisper-transcriber/internal/infrastructure"
 	"whisper-transcriber/pkg/models"
 )
 
+// defaultSpeedFactor is the assumed audio seconds transcribed per wall-clock
+// second, used for time estimates until a throughput has been recorded.
+const defaultSpeedFactor = 4.0
+
 type FileQueue struct {
-	mu    sync.Mutex
-	files []models.FileItem
+	mu        sync.Mutex
+	files     []models.FileItem
+	prober    models.MediaProber
+	storePath string
+	speed     float64
+}
+
+// NewFileQueue restores the queue persisted at storePath. Items that were
+// running when the app last exited are marked "interrupted" so they are
+// picked up again. Items still "hashing" are kept for the caller to hash.
+func NewFileQueue(prober models.MediaProber, storePath string) *FileQueue {
+	q := &FileQueue{prober: prober, storePath: storePath, speed: defaultSpeedFactor}
+
+	var files []models.FileItem
+	if err := infrastructure.ReadJSON(storePath, &files); err != nil {
+		return q
+	}
+	for i := range files {
+		switch files[i].Status {
+		case "extracting", "processing", "retrying":
+			files[i].Status = "interrupted"
+			files[i].Progress = 0
+		}
+	}
+	q.files = files
+	q.save()
+	return q
 }
 
-func NewFileQueue() *FileQueue {
-	return &FileQueue{}
+// save persists the queue. The caller must hold q.mu.
+func (q *FileQueue) save() {
+	if q.storePath == "" {
+		return
+	}
+	_ = infrastructure.WriteJSON(q.storePath, q.files)
 }
 
-func (q *FileQueue) Add(paths []string) []models.FileItem {
+// Add probes the given files and enqueues them with status "hashing"; they
+// are not run until Hash has checked them for duplicates. Files that cannot
+// be transcribed are returned with status "error" and not enqueued.
+func (q *FileQueue) Add(ctx context.Context, paths []string) []models.FileItem {
 	var items []models.FileItem
 	for _, path := range paths {
 		info, err := os.Stat(path)
 		if err != nil || info.IsDir() {
 			continue
 		}
-		items = append(items, models.FileItem{
+		item := models.FileItem{
 			ID:     models.GenerateID(),
 			Path:   path,
 			Name:   filepath.Base(path),
 			SizeMB: int(info.Size() / (1024 * 1024)),
-			Status: "pending",
-		})
+			Status: "hashing",
+		}
+
+		if err := q.probe(ctx, &item); err != nil {
+			item.Status = "error"
+			setError(&item, err)
+		}
+		items = append(items, item)
 	}
 
 	q.mu.Lock()
-	q.files = append(q.files, items...)
-	q.mu.Unlock()
+	defer q.mu.Unlock()
+	for i := range items {
+		if items[i].Status != "error" {
+			q.estimate(&items[i])
+			q.files = append(q.files, items[i])
+		}
+	}
+	q.save()
 
 	return items
 }
 
+// Hash hashes the items in ids that are still "hashing". Each becomes
+// "pending", or is removed as "duplicate" or "error"; onStatus reports the
+// progress and the outcome. Items not hashed when ctx ends are removed and
+// their IDs returned.
+func (q *FileQueue) Hash(ctx context.Context, ids []string, onStatus models.StatusFunc) []string {
+	var removed []string
+	for _, id := range ids {
+		item, ok := q.get(id)
+		if !ok || item.Status != "hashing" {
+			continue
+		}
+		if ctx.Err() != nil {
+			q.Remove(id)
+			removed = append(removed, id)
+			continue
+		}
+		hash, err := hashFile(ctx, item.Path, func(pct int) {
+			onStatus(id, "hashing", pct, nil)
+		})
+		if ctx.Err() != nil {
+			q.Remove(id)
+			removed = append(removed, id)
+			continue
+		}
+		if err != nil {
+			q.Remove(id)
+			onStatus(id, "error", 0, fmt.Errorf("hash: %w", err))
+			continue
+		}
+		status, err := q.setHash(id, hash)
+		if status != "" {
+			onStatus(id, status, 0, err)
+		}
+	}
+	return removed
+}
+
+// setHash records a finished hash and makes the item runnable, or removes it
+// if its content is already queued. It returns "" if the item is gone.
+func (q *FileQueue) setHash(id, hash string) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.indexOf(id)
+	if i < 0 || q.files[i].Status != "hashing" {
+		return "", nil
+	}
+	for _, f := range q.files {
+		if f.ID != id && f.Hash == hash {
+			q.files = append(q.files[:i], q.files[i+1:]...)
+			q.save()
+			return "duplicate", fmt.Errorf("same content as %s", f.Name)
+		}
+	}
+	q.files[i].Hash = hash
+	q.files[i].Status = "pending"
+	q.save()
+	return "pending", nil
+}
+
+func (q *FileQueue) get(id string) (models.FileItem, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if i := q.indexOf(id); i >= 0 {
+		return q.files[i], true
+	}
+	return models.FileItem{}, false
+}
+
+func (q *FileQueue) probe(ctx context.Context, item *models.FileItem) error {
+	if q.prober == nil {
+		return nil
+	}
+	media, err := q.prober.Probe(ctx, item.Path)
+	if errors.Is(err, models.ErrFFprobeNotFound) {
+		return nil
+	}
+	if err != nil {
+		var appErr *models.AppError
+		if errors.As(err, &appErr) {
+			return err
+		}
+		return models.NewError(models.CodeUnsupportedMedia, "unsupported media", err)
+	}
+	if len(media.AudioStreams) == 0 {
+		return models.ErrNoAudioStream
+	}
+	item.Media = media
+	return nil
+}
+
+// estimate sets the item's expected processing time from the selected
+// duration of each chosen track. The caller must hold q.mu.
+func (q *FileQueue) estimate(item *models.FileItem) {
+	if item.Media == nil {
+		return
+	}
+	tracks := max(1, len(item.AudioTracks))
+	item.EstimatedSec = selectedDuration(item.Ranges, item.Media.Duration) * float64(tracks) / q.speed
+}
+
+// SetSpeed sets the throughput, in audio seconds per second, that estimates
+// are based on and recomputes them.
+func (q *FileQueue) SetSpeed(audioPerSec float64) {
+	if audioPerSec <= 0 {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.speed = audioPerSec
+	for i := range q.files {
+		q.estimate(&q.files[i])
+	}
+	q.save()
+}
+
 func (q *FileQueue) Remove(id string) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	for i, f := range q.files {
 		if f.ID == id {
 			q.files = append(q.files[:i], q.files[i+1:]...)
+			q.save()
 			return
 		}
 	}
@@ -54,6 +222,7 @@ func (q *FileQueue) Remove(id string) {
 func (q *FileQueue) Clear() {
 	q.mu.Lock()
 	q.files = nil
+	q.save()
 	q.mu.Unlock()
 }
 
@@ -65,15 +234,227 @@ func (q *FileQueue) Snapshot() []models.FileItem {
 	return cp
 }
 
-func (q *FileQueue) UpdateStatus(id, status string, progress int, errMsg string) {
+// setError records err on the item as a message, code and details.
+func setError(item *models.FileItem, err error) {
+	if err == nil {
+		item.Error, item.ErrorCode, item.ErrorDetails = "", "", ""
+		return
+	}
+	info := models.DescribeError(err)
+	item.Error, item.ErrorCode, item.ErrorDetails = info.Message, info.Code, info.Details
+}
+
+func (q *FileQueue) UpdateStatus(id, status string, progress int, err error) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	for i := range q.files {
 		if q.files[i].ID == id {
+			// Progress ticks are frequent; only persist state transitions.
+			prevStatus, prevErr := q.files[i].Status, q.files[i].Error
+			setError(&q.files[i], err)
+			changed := prevStatus != status || prevErr != q.files[i].Error
 			q.files[i].Status = status
 			q.files[i].Progress = progress
-			q.files[i].Error = errMsg
+			if status == "extracting" && changed {
+				q.files[i].OutputPaths = nil
+			}
+			if changed {
+				q.save()
+			}
+			return
+		}
+	}
+}
+
+func (q *FileQueue) SetAudioTracks(id string, streamIndexes []int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.files {
+		if q.files[i].ID != id {
+			continue
+		}
+		if len(streamIndexes) > 0 && q.files[i].Media == nil {
+			return fmt.Errorf("no stream info for %s", q.files[i].Name)
+		}
+		for _, idx := range streamIndexes {
+			if !hasAudioStream(q.files[i].Media, idx) {
+				return fmt.Errorf("audio stream %d not found in %s", idx, q.files[i].Name)
+			}
+		}
+		q.files[i].AudioTracks = append([]int(nil), streamIndexes...)
+		q.estimate(&q.files[i])
+		q.save()
+		return nil
+	}
+	return fmt.Errorf("file not found: %s", id)
+}
+
+func (q *FileQueue) SetRanges(id string, ranges []models.TimeRange) error {
+	normalized, err := normalizeRanges(ranges)
+	if err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.files {
+		if q.files[i].ID == id {
+			q.files[i].Ranges = normalized
+			q.estimate(&q.files[i])
+			q.save()
+			return nil
+		}
+	}
+	return fmt.Errorf("file not found: %s", id)
+}
+
+// SetOutput makes the item write its output to outputDir in format instead
+// of where the batch writes. Empty values fall back to the batch's settings.
+func (q *FileQueue) SetOutput(id, outputDir, format string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.files {
+		if q.files[i].ID == id {
+			q.files[i].OutputDir = outputDir
+			q.files[i].OutputFormat = format
+			q.save()
+			return nil
+		}
+	}
+	return fmt.Errorf("file not found: %s", id)
+}
+
+func (q *FileQueue) AddOutput(id, outputPath string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.files {
+		if q.files[i].ID == id {
+			q.files[i].OutputPaths = append(q.files[i].OutputPaths, outputPath)
+			q.save()
 			return
 		}
 	}
 }
+
+// Requeue resets a failed, cancelled, interrupted or finished item to
+// "pending" so the next run picks it up. Items still being hashed or run are
+// left alone.
+func (q *FileQueue) Requeue(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.indexOf(id)
+	if i < 0 {
+		return fmt.Errorf("file not found: %s", id)
+	}
+	switch q.files[i].Status {
+	case "error", "cancelled", "interrupted", "done":
+	default:
+		return fmt.Errorf("cannot retry %s while it is %s", q.files[i].Name, q.files[i].Status)
+	}
+	q.files[i].Status = "pending"
+	q.files[i].Progress = 0
+	setError(&q.files[i], nil)
+	q.save()
+	return nil
+}
+
+// isRunnable reports whether a batch run should pick up an item. Failed,
+// cancelled and finished items are only re-run through an explicit retry.
+func isRunnable(status string) bool {
+	switch status {
+	case "pending", "interrupted":
+		return true
+	}
+	return false
+}
+
+// Next returns the highest-priority runnable item not in skip, keeping queue
+// order among equal priorities.
+func (q *FileQueue) Next(skip map[string]bool) (models.FileItem, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	best := -1
+	for i, f := range q.files {
+		if skip[f.ID] || !isRunnable(f.Status) {
+			continue
+		}
+		if best < 0 || f.Priority > q.files[best].Priority {
+			best = i
+		}
+	}
+	if best < 0 {
+		return models.FileItem{}, false
+	}
+	return q.files[best], true
+}
+
+// Move places an item at index, shifting the others.
+func (q *FileQueue) Move(id string, index int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	from := q.indexOf(id)
+	if from < 0 {
+		return fmt.Errorf("file not found: %s", id)
+	}
+	if index < 0 || index >= len(q.files) {
+		return fmt.Errorf("index out of range: %d", index)
+	}
+	item := q.files[from]
+	q.files = append(q.files[:from], q.files[from+1:]...)
+	q.files = append(q.files[:index], append([]models.FileItem{item}, q.files[index:]...)...)
+	q.save()
+	return nil
+}
+
+// Reorder sets the queue order. ids must list every queued item exactly once.
+func (q *FileQueue) Reorder(ids []string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(ids) != len(q.files) {
+		return fmt.Errorf("reorder needs all %d files, got %d", len(q.files), len(ids))
+	}
+	reordered := make([]models.FileItem, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		i := q.indexOf(id)
+		if i < 0 || seen[id] {
+			return fmt.Errorf("invalid or duplicate file: %s", id)
+		}
+		seen[id] = true
+		reordered = append(reordered, q.files[i])
+	}
+	q.files = reordered
+	q.save()
+	return nil
+}
+
+func (q *FileQueue) SetPriority(id string, priority int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i := q.indexOf(id)
+	if i < 0 {
+		return fmt.Errorf("file not found: %s", id)
+	}
+	q.files[i].Priority = priority
+	q.save()
+	return nil
+}
+
+// indexOf returns the position of id or -1. The caller must hold q.mu.
+func (q *FileQueue) indexOf(id string) int {
+	for i := range q.files {
+		if q.files[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func hasAudioStream(media *models.MediaInfo, idx int) bool {
+	for _, st := range media.AudioStreams {
+		if st.Index == idx {
+			return true
+		}
+	}
+	return false
+}
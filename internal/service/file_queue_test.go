@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func newTestQueue(files ...models.FileItem) *FileQueue {
+	q := NewFileQueue(nil, "")
+	q.files = files
+	return q
+}
+
+func queueIDs(q *FileQueue) []string {
+	var ids []string
+	for _, f := range q.Snapshot() {
+		ids = append(ids, f.ID)
+	}
+	return ids
+}
+
+func TestFileQueueNext(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  []models.FileItem
+		skip   map[string]bool
+		want   string
+		wantOK bool
+	}{
+		{name: "empty"},
+		{
+			name:   "queue order",
+			files:  []models.FileItem{{ID: "a", Status: "pending"}, {ID: "b", Status: "pending"}},
+			want:   "a",
+			wantOK: true,
+		},
+		{
+			name:   "priority first",
+			files:  []models.FileItem{{ID: "a", Status: "pending"}, {ID: "b", Status: "pending", Priority: 1}, {ID: "c", Status: "pending", Priority: 1}},
+			want:   "b",
+			wantOK: true,
+		},
+		{
+			name: "skips finished and failed",
+			files: []models.FileItem{
+				{ID: "a", Status: "done"}, {ID: "b", Status: "error"}, {ID: "c", Status: "hashing"},
+				{ID: "d", Status: "processing"}, {ID: "e", Status: "interrupted"},
+			},
+			want:   "e",
+			wantOK: true,
+		},
+		{
+			name:  "cancelled waits for retry",
+			files: []models.FileItem{{ID: "a", Status: "cancelled"}},
+		},
+		{
+			name:   "skip set",
+			files:  []models.FileItem{{ID: "a", Status: "pending", Priority: 2}, {ID: "b", Status: "pending"}},
+			skip:   map[string]bool{"a": true},
+			want:   "b",
+			wantOK: true,
+		},
+		{
+			name:  "all skipped",
+			files: []models.FileItem{{ID: "a", Status: "pending"}},
+			skip:  map[string]bool{"a": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := newTestQueue(tt.files...).Next(tt.skip)
+			if ok != tt.wantOK || got.ID != tt.want {
+				t.Errorf("got %q, %v; want %q, %v", got.ID, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFileQueueReorder(t *testing.T) {
+	tests := []struct {
+		name    string
+		ids     []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "reverse", ids: []string{"c", "b", "a"}, want: []string{"c", "b", "a"}},
+		{name: "same order", ids: []string{"a", "b", "c"}, want: []string{"a", "b", "c"}},
+		{name: "missing id", ids: []string{"a", "b"}, wantErr: true},
+		{name: "unknown id", ids: []string{"a", "b", "x"}, wantErr: true},
+		{name: "duplicate id", ids: []string{"a", "a", "b"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(models.FileItem{ID: "a"}, models.FileItem{ID: "b"}, models.FileItem{ID: "c"})
+			err := q.Reorder(tt.ids)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			want := tt.want
+			if tt.wantErr {
+				want = []string{"a", "b", "c"}
+			}
+			if got := queueIDs(q); !slices.Equal(got, want) {
+				t.Errorf("order %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFileQueueMove(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		index   int
+		want    []string
+		wantErr bool
+	}{
+		{name: "to front", id: "c", index: 0, want: []string{"c", "a", "b"}},
+		{name: "to back", id: "a", index: 2, want: []string{"b", "c", "a"}},
+		{name: "in place", id: "b", index: 1, want: []string{"a", "b", "c"}},
+		{name: "out of range", id: "a", index: 3, wantErr: true},
+		{name: "unknown id", id: "x", index: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(models.FileItem{ID: "a"}, models.FileItem{ID: "b"}, models.FileItem{ID: "c"})
+			err := q.Move(tt.id, tt.index)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !slices.Equal(queueIDs(q), tt.want) {
+				t.Errorf("order %v, want %v", queueIDs(q), tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFileQueueRestores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q := NewFileQueue(nil, path)
+	q.files = []models.FileItem{
+		{ID: "a", Status: "done", Progress: 100},
+		{ID: "b", Status: "extracting", Progress: 40},
+		{ID: "c", Status: "processing", Progress: 70},
+		{ID: "d", Status: "retrying", Progress: 10},
+		{ID: "e", Status: "pending"},
+		{ID: "f", Status: "hashing"},
+	}
+	q.Remove("e")
+
+	want := []models.FileItem{
+		{ID: "a", Status: "done", Progress: 100},
+		{ID: "b", Status: "interrupted"},
+		{ID: "c", Status: "interrupted"},
+		{ID: "d", Status: "interrupted"},
+		{ID: "f", Status: "hashing"},
+	}
+	restored := NewFileQueue(nil, path).Snapshot()
+	if len(restored) != len(want) {
+		t.Fatalf("restored %+v", restored)
+	}
+	for i, f := range restored {
+		if f.ID != want[i].ID || f.Status != want[i].Status || f.Progress != want[i].Progress {
+			t.Errorf("item %d: got %+v, want %+v", i, f, want[i])
+		}
+	}
+}
+
+func TestNewFileQueueWithoutStore(t *testing.T) {
+	dir := t.TempDir()
+	if files := NewFileQueue(nil, filepath.Join(dir, "missing.json")).Snapshot(); len(files) != 0 {
+		t.Errorf("missing store: %+v", files)
+	}
+	corrupt := filepath.Join(dir, "queue.json")
+	if err := os.WriteFile(corrupt, []byte("[{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if files := NewFileQueue(nil, corrupt).Snapshot(); len(files) != 0 {
+		t.Errorf("corrupt store: %+v", files)
+	}
+}
+
+func TestFileQueueHash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	queued := write("queued.wav", "same")
+	q := newTestQueue(
+		models.FileItem{ID: "old", Name: "queued.wav", Path: queued, Status: "done", Hash: "0967115f2813a3541eaef77de9d9d5773f1c0c04314b0bbfe4ff3b3b1c55b5d5"},
+		models.FileItem{ID: "a", Path: write("a.wav", "first"), Status: "hashing"},
+		models.FileItem{ID: "b", Path: write("b.wav", "first"), Status: "hashing"},
+		models.FileItem{ID: "c", Path: write("c.wav", "same"), Status: "hashing"},
+		models.FileItem{ID: "d", Path: filepath.Join(dir, "missing.wav"), Status: "hashing"},
+		models.FileItem{ID: "e", Path: write("e.wav", "other"), Status: "pending"},
+	)
+
+	final := make(map[string]string)
+	removed := q.Hash(context.Background(), []string{"a", "b", "c", "d", "e", "x"}, func(id, status string, _ int, _ error) {
+		if status != "hashing" {
+			final[id] = status
+		}
+	})
+	if len(removed) != 0 {
+		t.Errorf("removed %v", removed)
+	}
+	wantStatus := map[string]string{"a": "pending", "b": "duplicate", "c": "duplicate", "d": "error"}
+	if len(final) != len(wantStatus) {
+		t.Errorf("statuses %v, want %v", final, wantStatus)
+	}
+	for id, want := range wantStatus {
+		if final[id] != want {
+			t.Errorf("%s: %q, want %q", id, final[id], want)
+		}
+	}
+	if got := queueIDs(q); !slices.Equal(got, []string{"old", "a", "e"}) {
+		t.Errorf("queue %v", got)
+	}
+}
+
+func TestFileQueueHashCancelled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.wav")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	q := newTestQueue(
+		models.FileItem{ID: "a", Path: path, Status: "hashing"},
+		models.FileItem{ID: "b", Path: path, Status: "hashing"},
+		models.FileItem{ID: "c", Status: "pending"},
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	removed := q.Hash(ctx, []string{"a", "b"}, func(string, string, int, error) {})
+	if !slices.Equal(removed, []string{"a", "b"}) {
+		t.Errorf("removed %v", removed)
+	}
+	if got := queueIDs(q); !slices.Equal(got, []string{"c"}) {
+		t.Errorf("queue %v", got)
+	}
+}
+
+func TestFileQueueRequeue(t *testing.T) {
+	tests := []struct {
+		status  string
+		wantErr bool
+	}{
+		{status: "error"},
+		{status: "cancelled"},
+		{status: "interrupted"},
+		{status: "done"},
+		{status: "pending", wantErr: true},
+		{status: "hashing", wantErr: true},
+		{status: "extracting", wantErr: true},
+		{status: "processing", wantErr: true},
+		{status: "retrying", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			q := newTestQueue(models.FileItem{ID: "a", Status: tt.status, Progress: 40, Error: "boom"})
+			err := q.Requeue("a")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := q.Snapshot()[0]
+			want := models.FileItem{ID: "a", Status: "pending"}
+			if tt.wantErr {
+				want = models.FileItem{ID: "a", Status: tt.status, Progress: 40, Error: "boom"}
+			}
+			if got.Status != want.Status || got.Progress != want.Progress || got.Error != want.Error {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	if err := newTestQueue().Requeue("missing"); err == nil {
+		t.Error("want error for an unknown file")
+	}
+}
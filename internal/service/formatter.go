@@ -17,34 +17,38 @@ func NewFormatter() *Formatter {
 }
 
 func (f *Formatter) WriteOutput(result *models.TranscriptionResult, sourcePath, format string) (string, error) {
-	valid := map[string]bool{"txt": true, "srt": true, "json": true, "md": true}
-	if !valid[format] {
-		return "", fmt.Errorf("unsupported format: %s", format)
+	content, err := f.Render(result, format)
+	if err != nil {
+		return "", err
 	}
 
 	base := strings.TrimSuffix(sourcePath, filepath.Ext(sourcePath))
+	if result.Track != "" {
+		base += "." + result.Track
+	}
 	outPath := base + "." + format
 
-	var content string
-	var err error
+	return outPath, diskError(os.WriteFile(outPath, []byte(content), 0644))
+}
 
+// Render returns the result in the given output format.
+func (f *Formatter) Render(result *models.TranscriptionResult, format string) (string, error) {
 	switch format {
 	case "txt":
-		content = formatTXT(result)
+		return formatTXT(result), nil
 	case "srt":
-		content = formatSRT(result)
+		return formatSRT(result), nil
+	case "vtt":
+		return formatVTT(result), nil
+	case "ass":
+		return formatASS(result), nil
 	case "json":
-		content, err = formatJSON(result)
-		if err != nil {
-			return "", err
-		}
+		return formatJSON(result)
 	case "md":
-		content = formatMarkdown(result)
+		return formatMarkdown(result), nil
 	default:
-		return "", fmt.Errorf("unknown format: %s", format)
+		return "", fmt.Errorf("unsupported format: %s", format)
 	}
-
-	return outPath, os.WriteFile(outPath, []byte(content), 0644)
 }
 
 func formatTXT(r *models.TranscriptionResult) string {
@@ -52,7 +56,7 @@ func formatTXT(r *models.TranscriptionResult) string {
 	for _, seg := range r.Segments {
 		mm := int(seg.Start) / 60
 		ss := int(seg.Start) % 60
-		sb.WriteString(fmt.Sprintf("[%02d:%02d] %s\n", mm, ss, strings.TrimSpace(seg.Text)))
+		sb.WriteString(fmt.Sprintf("[%02d:%02d] %s%s\n", mm, ss, speakerPrefix(r, seg), flatten(seg.Text)))
 	}
 	return sb.String()
 }
@@ -62,17 +66,60 @@ func formatSRT(r *models.TranscriptionResult) string {
 	for i, seg := range r.Segments {
 		sb.WriteString(fmt.Sprintf("%d\n", i+1))
 		sb.WriteString(fmt.Sprintf("%s --> %s\n", srtTime(seg.Start), srtTime(seg.End)))
-		sb.WriteString(strings.TrimSpace(seg.Text) + "\n\n")
+		sb.WriteString(speakerPrefix(r, seg) + strings.TrimSpace(seg.Text) + "\n\n")
 	}
 	return sb.String()
 }
 
 func srtTime(seconds float64) string {
-	h := int(seconds) / 3600
-	m := (int(seconds) % 3600) / 60
-	s := int(seconds) % 60
-	ms := int((seconds - float64(int(seconds))) * 1000)
-	return fmt.Sprintf("%02d:%02d:%02d,%03d", h, m, s, ms)
+	ms := int(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, (ms/60000)%60, (ms/1000)%60, ms%1000)
+}
+
+func formatVTT(r *models.TranscriptionResult) string {
+	var sb strings.Builder
+	sb.WriteString("WEBVTT\n\n")
+	for _, seg := range r.Segments {
+		sb.WriteString(fmt.Sprintf("%s --> %s\n", vttTime(seg.Start), vttTime(seg.End)))
+		if name := speakerName(r, seg); name != "" {
+			sb.WriteString("<v " + name + ">")
+		}
+		sb.WriteString(strings.TrimSpace(seg.Text) + "\n\n")
+	}
+	return sb.String()
+}
+
+func vttTime(seconds float64) string {
+	return strings.Replace(srtTime(seconds), ",", ".", 1)
+}
+
+const assHeader = `[Script Info]
+ScriptType: v4.00+
+WrapStyle: 0
+ScaledBorderAndShadow: yes
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,20,&H00FFFFFF,&H000000FF,&H00000000,&H64000000,0,0,0,0,100,100,0,0,1,2,1,2,10,10,10,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+`
+
+func formatASS(r *models.TranscriptionResult) string {
+	var sb strings.Builder
+	sb.WriteString(assHeader)
+	for _, seg := range r.Segments {
+		text := strings.ReplaceAll(strings.TrimSpace(seg.Text), "\n", `\N`)
+		sb.WriteString(fmt.Sprintf("Dialogue: 0,%s,%s,Default,%s,0,0,0,,%s\n",
+			assTime(seg.Start), assTime(seg.End), strings.ReplaceAll(speakerName(r, seg), ",", " "), text))
+	}
+	return sb.String()
+}
+
+func assTime(seconds float64) string {
+	cs := int(seconds*100 + 0.5)
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, (cs/6000)%60, (cs/100)%60, cs%100)
 }
 
 func formatJSON(r *models.TranscriptionResult) (string, error) {
@@ -86,10 +133,39 @@ func formatJSON(r *models.TranscriptionResult) (string, error) {
 func formatMarkdown(r *models.TranscriptionResult) string {
 	var sb strings.Builder
 	sb.WriteString("# Transcription\n\n")
+	if len(r.Filters) > 0 {
+		sb.WriteString(fmt.Sprintf("Preprocessing: `%s`\n\n", strings.Join(r.Filters, "`, `")))
+	}
 	for _, seg := range r.Segments {
 		mm := int(seg.Start) / 60
 		ss := int(seg.Start) % 60
-		sb.WriteString(fmt.Sprintf("**[%02d:%02d]** %s\n\n", mm, ss, strings.TrimSpace(seg.Text)))
+		speaker := ""
+		if name := speakerName(r, seg); name != "" {
+			speaker = "**" + name + ":** "
+		}
+		sb.WriteString(fmt.Sprintf("**[%02d:%02d]** %s%s\n\n", mm, ss, speaker, flatten(seg.Text)))
 	}
 	return sb.String()
 }
+
+func speakerName(r *models.TranscriptionResult, seg models.Segment) string {
+	if seg.Speaker == "" {
+		return ""
+	}
+	if name, ok := r.Speakers[seg.Speaker]; ok && name != "" {
+		return name
+	}
+	return seg.Speaker
+}
+
+func speakerPrefix(r *models.TranscriptionResult, seg models.Segment) string {
+	if name := speakerName(r, seg); name != "" {
+		return name + ": "
+	}
+	return ""
+}
+
+// flatten joins a multi-line cue into one line for the plain text formats.
+func flatten(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"whisper-transcriber/pkg/models"
+)
+
+const (
+	issueRepetition    = "repetition"
+	issueLoop          = "loop"
+	issueLowConfidence = "low_confidence"
+	issueKnownPhrase   = "known_phrase"
+
+	minRepeatRun     = 3
+	minLoopRepeats   = 4
+	maxLoopPhrase    = 4
+	maxPhraseTail    = 3
+	lowConfidence    = 0.4
+	quietSegmentDB   = -45.0
+	redecodeTemp     = 0.6
+	minRedecodeAudio = 1.0
+)
+
+// knownHallucinations are phrases Whisper tends to produce on silence or
+// music, learned from subtitle-heavy training data.
+var knownHallucinations = []string{
+	"thanks for watching",
+	"thank you for watching",
+	"please subscribe",
+	"like and subscribe",
+	"subscribe to my channel",
+	"subtitles by",
+	"subtitles by the amara org community",
+	"amara org",
+	"продолжение следует",
+	"редактор субтитров",
+	"субтитры сделал",
+	"субтитры создавал",
+	"спасибо за просмотр",
+}
+
+func ValidCleanupAction(action string) bool {
+	switch action {
+	case "", "off", "flag", "drop", "redecode":
+		return true
+	}
+	return false
+}
+
+type cleanupFuncs struct {
+	// level returns the segment's audio level in dBFS.
+	level func(models.Segment) float64
+	// redecode transcribes the segment's audio again with a higher
+	// temperature.
+	redecode func(models.Segment) (string, error)
+}
+
+// cleanSegments detects looping text, known hallucination phrases and
+// low-confidence segments on quiet audio, then flags, drops or re-decodes
+// them depending on action.
+func cleanSegments(segments []models.Segment, action string, fn cleanupFuncs) ([]models.Segment, *models.CleanupReport) {
+	report := &models.CleanupReport{Action: action}
+	issues := segmentIssues(segments, fn.level)
+
+	var out []models.Segment
+	for i, seg := range segments {
+		found := issues[i]
+		if len(found) == 0 {
+			out = append(out, seg)
+			continue
+		}
+		for _, issue := range found {
+			switch issue {
+			case issueRepetition, issueLoop:
+				report.Repetitions++
+			case issueLowConfidence:
+				report.LowConfidence++
+			case issueKnownPhrase:
+				report.KnownPhrases++
+			}
+		}
+
+		if action == "flag" {
+			seg.Flags = found
+			report.Flagged++
+			out = append(out, seg)
+			continue
+		}
+
+		// A loop inside an otherwise valid segment is collapsed rather than
+		// dropped.
+		if len(found) == 1 && found[0] == issueLoop {
+			seg.Text = collapseLoop(seg.Text)
+			report.Collapsed++
+			out = append(out, seg)
+			continue
+		}
+
+		if action == "redecode" && fn.redecode != nil {
+			text, err := fn.redecode(seg)
+			if err == nil && strings.TrimSpace(text) != "" && !isKnownHallucination(normalizeText(text)) && !hasLoop(text) {
+				seg.Text = text
+				report.Redecoded++
+				out = append(out, seg)
+				continue
+			}
+		}
+
+		report.Dropped++
+	}
+
+	for i := range out {
+		out[i].Index = i
+	}
+	return out, report
+}
+
+func segmentIssues(segments []models.Segment, level func(models.Segment) float64) [][]string {
+	issues := make([][]string, len(segments))
+	norm := make([]string, len(segments))
+	for i, seg := range segments {
+		norm[i] = normalizeText(seg.Text)
+	}
+
+	for i := 0; i < len(segments); {
+		j := i + 1
+		for j < len(segments) && norm[j] != "" && norm[j] == norm[i] {
+			j++
+		}
+		if norm[i] != "" && j-i >= minRepeatRun {
+			for k := i + 1; k < j; k++ {
+				issues[k] = append(issues[k], issueRepetition)
+			}
+		}
+		i = j
+	}
+
+	for i, seg := range segments {
+		if hasLoop(seg.Text) {
+			issues[i] = append(issues[i], issueLoop)
+		}
+		if isKnownHallucination(norm[i]) {
+			issues[i] = append(issues[i], issueKnownPhrase)
+		}
+		if seg.Confidence > 0 && seg.Confidence < lowConfidence && level != nil && level(seg) < quietSegmentDB {
+			issues[i] = append(issues[i], issueLowConfidence)
+		}
+	}
+	return issues
+}
+
+func normalizeText(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && sb.Len() > 0 {
+				sb.WriteRune(' ')
+			}
+			sb.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return sb.String()
+}
+
+// isKnownHallucination reports whether the whole segment is a known phrase,
+// allowing a few trailing words such as a name after "subtitles by". A
+// phrase inside longer text is left alone, since it is likely real speech.
+func isKnownHallucination(norm string) bool {
+	for _, phrase := range knownHallucinations {
+		rest, ok := strings.CutPrefix(norm, phrase)
+		if !ok || (rest != "" && rest[0] != ' ') {
+			continue
+		}
+		if len(strings.Fields(rest)) <= maxPhraseTail {
+			return true
+		}
+	}
+	return false
+}
+
+// findLoop looks for a phrase of up to maxLoopPhrase words repeated at least
+// minLoopRepeats times in a row and returns its word offset and length.
+func findLoop(words []string) (start, size, repeats int) {
+	for size = 1; size <= maxLoopPhrase; size++ {
+		for start = 0; start+size*minLoopRepeats <= len(words); start++ {
+			repeats = 1
+			for next := start + size; next+size <= len(words); next += size {
+				if !equalWords(words[start:start+size], words[next:next+size]) {
+					break
+				}
+				repeats++
+			}
+			if repeats >= minLoopRepeats {
+				return start, size, repeats
+			}
+		}
+	}
+	return 0, 0, 0
+}
+
+func hasLoop(text string) bool {
+	_, size, _ := findLoop(loopWords(text))
+	return size > 0
+}
+
+func collapseLoop(text string) string {
+	words := strings.Fields(text)
+	for {
+		start, size, repeats := findLoop(loopWords(strings.Join(words, " ")))
+		if size == 0 {
+			return " " + strings.Join(words, " ")
+		}
+		words = append(words[:start+size], words[start+size*repeats:]...)
+	}
+}
+
+// loopWords splits text like strings.Fields but compares words without
+// punctuation or case.
+func loopWords(text string) []string {
+	fields := strings.Fields(text)
+	for i, f := range fields {
+		fields[i] = normalizeText(f)
+	}
+	return fields
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func segmentLevel(samples []float32, sampleRate int, seg models.Segment) float64 {
+	from := int(seg.Start * float64(sampleRate))
+	to := int(seg.End * float64(sampleRate))
+	if from < 0 {
+		from = 0
+	}
+	if to > len(samples) {
+		to = len(samples)
+	}
+	if from >= to {
+		return math.Inf(-1)
+	}
+	var sum float64
+	for _, s := range samples[from:to] {
+		sum += float64(s) * float64(s)
+	}
+	return 10 * math.Log10(sum/float64(to-from)+1e-12)
+}
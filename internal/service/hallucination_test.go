@@ -0,0 +1,136 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestFindLoop(t *testing.T) {
+	tests := []struct {
+		name                string
+		text                string
+		start, size, repeat int
+	}{
+		{name: "no loop", text: "the quick brown fox jumps"},
+		{name: "too few repeats", text: "no no no"},
+		{name: "single word", text: "well no no no no fine", start: 1, size: 1, repeat: 4},
+		{name: "phrase", text: "i said go on go on go on go on", start: 2, size: 2, repeat: 4},
+		{name: "ignores case and punctuation", text: "Yes. yes, YES! yes", start: 0, size: 1, repeat: 4},
+		{name: "phrase too long", text: strings.Repeat("one two three four five ", 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, size, repeat := findLoop(loopWords(tt.text))
+			if start != tt.start || size != tt.size || repeat != tt.repeat {
+				t.Errorf("got (%d, %d, %d), want (%d, %d, %d)", start, size, repeat, tt.start, tt.size, tt.repeat)
+			}
+		})
+	}
+}
+
+func TestCollapseLoop(t *testing.T) {
+	got := collapseLoop("and then go on go on go on go on we stopped")
+	if want := " and then go on we stopped"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIsKnownHallucination(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"Thanks for watching!", true},
+		{"thanks for watching everyone", true},
+		{"Subtitles by the Amara.org community", true},
+		{"Продолжение следует...", true},
+		{"", false},
+		{"I wanted to say thanks for watching the kids last weekend", false},
+		{"please subscribe to the newsletter for weekly updates", false},
+		{"thanks for watchingmore", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := isKnownHallucination(normalizeText(tt.text)); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanSegments(t *testing.T) {
+	quiet := func(models.Segment) float64 { return -60 }
+	segments := []models.Segment{
+		{Text: " Hello there."},
+		{Text: " Hello there."},
+		{Text: " Hello there."},
+		{Text: " Thanks for watching!"},
+		{Text: " go on go on go on go on"},
+		{Text: " mumble", Confidence: 0.2},
+		{Text: " Goodbye.", Confidence: 0.9},
+	}
+	tests := []struct {
+		name   string
+		action string
+		texts  []string
+		flags  [][]string
+	}{
+		{
+			name:   "drop",
+			action: "drop",
+			texts:  []string{" Hello there.", " go on", " Goodbye."},
+		},
+		{
+			name:   "flag",
+			action: "flag",
+			texts:  []string{" Hello there.", " Hello there.", " Hello there.", " Thanks for watching!", " go on go on go on go on", " mumble", " Goodbye."},
+			flags: [][]string{
+				nil,
+				{issueRepetition},
+				{issueRepetition},
+				{issueKnownPhrase},
+				{issueLoop},
+				{issueLowConfidence},
+				nil,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _ := cleanSegments(slices.Clone(segments), tt.action, cleanupFuncs{level: quiet})
+			var texts []string
+			for i, seg := range out {
+				texts = append(texts, seg.Text)
+				if seg.Index != i {
+					t.Errorf("segment %d has index %d", i, seg.Index)
+				}
+				if tt.flags != nil && !slices.Equal(seg.Flags, tt.flags[i]) {
+					t.Errorf("segment %d: flags %v, want %v", i, seg.Flags, tt.flags[i])
+				}
+			}
+			if !slices.Equal(texts, tt.texts) {
+				t.Errorf("got %q, want %q", texts, tt.texts)
+			}
+		})
+	}
+}
+
+func TestCleanSegmentsRedecode(t *testing.T) {
+	segments := []models.Segment{{Text: " Thanks for watching!"}, {Text: " Subtitles by Amara"}}
+	fn := cleanupFuncs{redecode: func(seg models.Segment) (string, error) {
+		if strings.Contains(seg.Text, "Thanks") {
+			return " The meeting is adjourned.", nil
+		}
+		return " Subtitles by Amara", nil
+	}}
+	out, report := cleanSegments(segments, "redecode", fn)
+	if len(out) != 1 || out[0].Text != " The meeting is adjourned." {
+		t.Fatalf("got %v", out)
+	}
+	if report.Redecoded != 1 || report.Dropped != 1 || report.KnownPhrases != 2 {
+		t.Errorf("report %+v", report)
+	}
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"whisper-transcriber/internal/infrastructure"
+	"whisper-transcriber/pkg/models"
+)
+
+// Library keeps every finished transcript under dir: index.json lists the
+// entries and each transcript is stored as <id>.json. Search uses an
+// in-memory word index that is built from the stored transcripts on first
+// use.
+type Library struct {
+	mu      sync.Mutex
+	dir     string
+	entries []models.LibraryEntry
+	// words maps each normalized word to the IDs of entries containing it.
+	words map[string]map[string]bool
+}
+
+func NewLibrary(dir string) *Library {
+	l := &Library{dir: dir}
+	_ = infrastructure.ReadJSON(l.indexPath(), &l.entries)
+	return l
+}
+
+func (l *Library) Add(result *models.TranscriptionResult, outputPath string) (models.LibraryEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	entry := models.LibraryEntry{
+		ID:         models.GenerateID(),
+		Title:      libraryTitle(result),
+		SourcePath: result.FilePath,
+		OutputPath: outputPath,
+		Track:      result.Track,
+		Language:   result.Language,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	describeResult(&entry, result)
+
+	if err := infrastructure.WriteJSON(l.resultPath(entry.ID), result); err != nil {
+		return models.LibraryEntry{}, fmt.Errorf("store transcript: %w", err)
+	}
+	l.entries = append(l.entries, entry)
+	if err := l.save(); err != nil {
+		return models.LibraryEntry{}, err
+	}
+	if l.words != nil {
+		l.indexResult(entry.ID, result)
+	}
+	return entry, nil
+}
+
+// List returns the entries, newest first.
+func (l *Library) List() []models.LibraryEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]models.LibraryEntry, len(l.entries))
+	copy(out, l.entries)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Search returns the transcripts containing every query word, newest first.
+// Query words match as prefixes, so "transcri" finds "transcription". Each
+// hit lists the segments containing any of the words.
+func (l *Library) Search(query string) ([]models.LibraryHit, error) {
+	terms := strings.Fields(normalizeText(query))
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buildIndex()
+
+	var candidates map[string]bool
+	for _, term := range terms {
+		ids := make(map[string]bool)
+		for word, posting := range l.words {
+			if strings.HasPrefix(word, term) {
+				for id := range posting {
+					ids[id] = true
+				}
+			}
+		}
+		if candidates != nil {
+			for id := range candidates {
+				if !ids[id] {
+					delete(candidates, id)
+				}
+			}
+		} else {
+			candidates = ids
+		}
+	}
+
+	var hits []models.LibraryHit
+	for _, entry := range l.entries {
+		if !candidates[entry.ID] {
+			continue
+		}
+		result, err := l.load(entry.ID)
+		if err != nil {
+			continue
+		}
+		hit := models.LibraryHit{Entry: entry}
+		for _, seg := range result.Segments {
+			if containsAnyPrefix(strings.Fields(normalizeText(seg.Text)), terms) {
+				hit.Segments = append(hit.Segments, seg)
+			}
+		}
+		hits = append(hits, hit)
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Entry.CreatedAt.After(hits[j].Entry.CreatedAt) })
+	return hits, nil
+}
+
+func (l *Library) Entry(id string) (models.LibraryEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	i := l.indexOf(id)
+	if i < 0 {
+		return models.LibraryEntry{}, fmt.Errorf("transcript not found: %s", id)
+	}
+	return l.entries[i], nil
+}
+
+func (l *Library) Get(id string) (*models.TranscriptionResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.indexOf(id) < 0 {
+		return nil, fmt.Errorf("transcript not found: %s", id)
+	}
+	return l.load(id)
+}
+
+// Update replaces a stored transcript, for example after editing.
+func (l *Library) Update(id string, result *models.TranscriptionResult) (models.LibraryEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	i := l.indexOf(id)
+	if i < 0 {
+		return models.LibraryEntry{}, fmt.Errorf("transcript not found: %s", id)
+	}
+	if err := infrastructure.WriteJSON(l.resultPath(id), result); err != nil {
+		return models.LibraryEntry{}, fmt.Errorf("store transcript: %w", err)
+	}
+	entry := &l.entries[i]
+	entry.UpdatedAt = time.Now()
+	describeResult(entry, result)
+	if err := l.save(); err != nil {
+		return models.LibraryEntry{}, err
+	}
+	if l.words != nil {
+		l.unindex(id)
+		l.indexResult(id, result)
+	}
+	return *entry, nil
+}
+
+func (l *Library) Delete(id string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	i := l.indexOf(id)
+	if i < 0 {
+		return fmt.Errorf("transcript not found: %s", id)
+	}
+	l.entries = append(l.entries[:i], l.entries[i+1:]...)
+	if err := l.save(); err != nil {
+		return err
+	}
+	l.unindex(id)
+	for _, path := range []string{l.resultPath(id), historyPath(l.dir, id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildIndex indexes every stored transcript. The caller must hold l.mu.
+func (l *Library) buildIndex() {
+	if l.words != nil {
+		return
+	}
+	l.words = make(map[string]map[string]bool)
+	for _, entry := range l.entries {
+		result, err := l.load(entry.ID)
+		if err != nil {
+			continue
+		}
+		l.indexResult(entry.ID, result)
+	}
+}
+
+func (l *Library) indexResult(id string, result *models.TranscriptionResult) {
+	for _, seg := range result.Segments {
+		for _, word := range strings.Fields(normalizeText(seg.Text)) {
+			posting := l.words[word]
+			if posting == nil {
+				posting = make(map[string]bool)
+				l.words[word] = posting
+			}
+			posting[id] = true
+		}
+	}
+}
+
+func (l *Library) unindex(id string) {
+	for word, posting := range l.words {
+		delete(posting, id)
+		if len(posting) == 0 {
+			delete(l.words, word)
+		}
+	}
+}
+
+func (l *Library) load(id string) (*models.TranscriptionResult, error) {
+	var result models.TranscriptionResult
+	if err := infrastructure.ReadJSON(l.resultPath(id), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// save persists the index. The caller must hold l.mu.
+func (l *Library) save() error {
+	return infrastructure.WriteJSON(l.indexPath(), l.entries)
+}
+
+func (l *Library) indexOf(id string) int {
+	for i, entry := range l.entries {
+		if entry.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (l *Library) indexPath() string {
+	return filepath.Join(l.dir, "index.json")
+}
+
+func (l *Library) resultPath(id string) string {
+	return filepath.Join(l.dir, id+".json")
+}
+
+func libraryTitle(result *models.TranscriptionResult) string {
+	title := strings.TrimSuffix(filepath.Base(result.FilePath), filepath.Ext(result.FilePath))
+	if result.Track != "" {
+		title += " [" + result.Track + "]"
+	}
+	return title
+}
+
+func describeResult(entry *models.LibraryEntry, result *models.TranscriptionResult) {
+	entry.Segments = len(result.Segments)
+	entry.Duration = 0
+	if n := len(result.Segments); n > 0 {
+		entry.Duration = result.Segments[n-1].End
+	}
+}
+
+func containsAnyPrefix(words, terms []string) bool {
+	for _, word := range words {
+		for _, term := range terms {
+			if strings.HasPrefix(word, term) {
+				return true
+			}
+		}
+	}
+	return false
+}
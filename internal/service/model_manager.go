@@ -35,6 +35,13 @@ func (m *ModelMgr) IsModelAvailable() bool {
 	return err == nil && info.Size() > 0
 }
 
+// InstalledModels returns the paths of the ggml model files in the models
+// folder.
+func (m *ModelMgr) InstalledModels() []string {
+	paths, _ := filepath.Glob(filepath.Join(m.modelDir, "ggml-*.bin"))
+	return paths
+}
+
 func (m *ModelMgr) DownloadModel(ctx context.Context, onProgress models.ProgressFunc) error {
 	if err := os.MkdirAll(m.modelDir, 0755); err != nil {
 		return fmt.Errorf("cannot create models dir: %w", err)
@@ -45,7 +52,7 @@ func (m *ModelMgr) DownloadModel(ctx context.Context, onProgress models.Progress
 
 	resp, err := infrastructure.HTTPGetWithRetry(ctx, modelURL, 3)
 	if err != nil {
-		return fmt.Errorf("download request failed: %w", err)
+		return models.NewError(models.CodeNetwork, "download failed; check your internet connection", err)
 	}
 	defer resp.Body.Close()
 
@@ -68,7 +75,7 @@ func (m *ModelMgr) DownloadModel(ctx context.Context, onProgress models.Progress
 		n, readErr := resp.Body.Read(buf)
 		if n > 0 {
 			if _, writeErr := out.Write(buf[:n]); writeErr != nil {
-				return writeErr
+				return diskError(writeErr)
 			}
 			downloaded += int64(n)
 			if total > 0 && onProgress != nil {
@@ -82,7 +89,7 @@ func (m *ModelMgr) DownloadModel(ctx context.Context, onProgress models.Progress
 			break
 		}
 		if readErr != nil {
-			return readErr
+			return models.NewError(models.CodeNetwork, "download interrupted", readErr)
 		}
 	}
 
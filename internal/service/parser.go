@@ -0,0 +1,311 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"whisper-transcriber/pkg/models"
+)
+
+// ParseFormats lists the extensions ParseTranscript understands.
+var ParseFormats = []string{".srt", ".vtt", ".ass", ".ssa", ".json"}
+
+// ParseTranscript reads a subtitle or transcript file back into a result.
+// Line breaks inside a cue are kept; speakers come from WebVTT voice tags and
+// the ASS Name field.
+func ParseTranscript(path string) (*models.TranscriptionResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var result *models.TranscriptionResult
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".srt":
+		result, err = parseSRT(f)
+	case ".vtt":
+		result, err = parseVTT(f)
+	case ".ass", ".ssa":
+		result, err = parseASS(f)
+	case ".json":
+		result, err = parseJSON(f)
+	default:
+		return nil, fmt.Errorf("unsupported transcript format: %s", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	if result.FilePath == "" {
+		result.FilePath = path
+	}
+	renumber(result)
+	return result, nil
+}
+
+// cue is a parsed subtitle block before speakers are assigned IDs.
+type cue struct {
+	start, end float64
+	speaker    string
+	lines      []string
+}
+
+// readBlocks splits input into blank-line separated blocks of trimmed lines.
+func readBlocks(r io.Reader) ([][]string, error) {
+	var blocks [][]string
+	var block []string
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	first := true
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+		if strings.TrimSpace(line) == "" {
+			if len(block) > 0 {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		block = append(block, strings.TrimSpace(line))
+	}
+	if len(block) > 0 {
+		blocks = append(blocks, block)
+	}
+	return blocks, sc.Err()
+}
+
+func parseSRT(r io.Reader) (*models.TranscriptionResult, error) {
+	blocks, err := readBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+	var cues []cue
+	for _, block := range blocks {
+		c, ok, err := parseCueBlock(block)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			c.lines = stripMarkup(c.lines)
+			cues = append(cues, c)
+		}
+	}
+	return buildResult(cues), nil
+}
+
+var vttVoice = regexp.MustCompile(`^<v(?:\.[^\s>]*)?\s+([^>]+)>`)
+
+func parseVTT(r io.Reader) (*models.TranscriptionResult, error) {
+	blocks, err := readBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 || !strings.HasPrefix(blocks[0][0], "WEBVTT") {
+		return nil, fmt.Errorf("missing WEBVTT header")
+	}
+
+	var cues []cue
+	for _, block := range blocks[1:] {
+		switch {
+		case strings.HasPrefix(block[0], "NOTE"), block[0] == "STYLE", block[0] == "REGION":
+			continue
+		}
+		c, ok, err := parseCueBlock(block)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if m := vttVoice.FindStringSubmatch(c.lines[0]); m != nil {
+			c.speaker = strings.TrimSpace(m[1])
+		}
+		c.lines = stripMarkup(c.lines)
+		cues = append(cues, c)
+	}
+	return buildResult(cues), nil
+}
+
+// parseCueBlock reads an SRT or WebVTT cue: an optional identifier line, a
+// timing line and the text. Blocks without a timing line are skipped.
+func parseCueBlock(block []string) (cue, bool, error) {
+	i := 0
+	if !strings.Contains(block[0], "-->") {
+		i = 1
+	}
+	if i >= len(block) || !strings.Contains(block[i], "-->") {
+		return cue{}, false, nil
+	}
+
+	parts := strings.SplitN(block[i], "-->", 2)
+	start, err := parseTimestamp(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return cue{}, false, err
+	}
+	// WebVTT cue settings follow the end time.
+	endField := strings.Fields(parts[1])
+	if len(endField) == 0 {
+		return cue{}, false, fmt.Errorf("missing end time in %q", block[i])
+	}
+	end, err := parseTimestamp(endField[0])
+	if err != nil {
+		return cue{}, false, err
+	}
+	if len(block[i+1:]) == 0 {
+		return cue{}, false, nil
+	}
+	return cue{start: start, end: end, lines: block[i+1:]}, true, nil
+}
+
+// parseTimestamp accepts [hh:]mm:ss with a ',' or '.' fraction, as used by
+// SRT, WebVTT and ASS.
+func parseTimestamp(s string) (float64, error) {
+	s = strings.Replace(s, ",", ".", 1)
+	fields := strings.Split(s, ":")
+	if len(fields) < 2 || len(fields) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	var total float64
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		if i < len(fields)-1 {
+			total = (total + v) * 60
+		} else {
+			total += v
+		}
+	}
+	return total, nil
+}
+
+func parseASS(r io.Reader) (*models.TranscriptionResult, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var cues []cue
+	var format []string
+	inEvents := false
+	for sc.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(sc.Text(), "\ufeff"))
+		if strings.HasPrefix(line, "[") {
+			inEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Format":
+			format = nil
+			for _, field := range strings.Split(value, ",") {
+				format = append(format, strings.ToLower(strings.TrimSpace(field)))
+			}
+		case "Dialogue":
+			if format == nil {
+				return nil, fmt.Errorf("dialogue before format line")
+			}
+			// Text is the last field and may itself contain commas.
+			values := strings.SplitN(strings.TrimSpace(value), ",", len(format))
+			if len(values) != len(format) {
+				continue
+			}
+			fields := make(map[string]string, len(format))
+			for i, name := range format {
+				fields[name] = values[i]
+			}
+			start, err := parseTimestamp(strings.TrimSpace(fields["start"]))
+			if err != nil {
+				return nil, err
+			}
+			end, err := parseTimestamp(strings.TrimSpace(fields["end"]))
+			if err != nil {
+				return nil, err
+			}
+			text := assOverride.ReplaceAllString(fields["text"], "")
+			text = strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(text)
+			cues = append(cues, cue{
+				start:   start,
+				end:     end,
+				speaker: strings.TrimSpace(fields["name"]),
+				lines:   strings.Split(text, "\n"),
+			})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return buildResult(cues), nil
+}
+
+var (
+	assOverride = regexp.MustCompile(`\{[^}]*\}`)
+	markupTag   = regexp.MustCompile(`</?[^>]+>`)
+)
+
+// stripMarkup removes HTML-style tags and ASS override blocks some SRT and
+// WebVTT files carry.
+func stripMarkup(lines []string) []string {
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = markupTag.ReplaceAllString(line, "")
+		line = assOverride.ReplaceAllString(line, "")
+		line = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&nbsp;", " ").Replace(line)
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+func parseJSON(r io.Reader) (*models.TranscriptionResult, error) {
+	var result models.TranscriptionResult
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// buildResult turns cues into segments, giving each distinct speaker name an
+// ID the way diarization does.
+func buildResult(cues []cue) *models.TranscriptionResult {
+	result := &models.TranscriptionResult{Segments: []models.Segment{}}
+	ids := make(map[string]string)
+	for _, c := range cues {
+		text := strings.TrimSpace(strings.Join(c.lines, "\n"))
+		if text == "" {
+			continue
+		}
+		seg := models.Segment{Start: c.start, End: c.end, Text: text}
+		if c.speaker != "" {
+			id, ok := ids[c.speaker]
+			if !ok {
+				id = fmt.Sprintf("S%d", len(ids)+1)
+				ids[c.speaker] = id
+				if result.Speakers == nil {
+					result.Speakers = make(map[string]string)
+				}
+				result.Speakers[id] = c.speaker
+			}
+			seg.Speaker = id
+		}
+		result.Segments = append(result.Segments, seg)
+	}
+	return result
+}
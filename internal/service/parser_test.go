@@ -0,0 +1,139 @@
+package service
+
+import (
+	"io"
+	"maps"
+	"strings"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "00:00:01,500", want: 1.5},
+		{in: "01:02:03.250", want: 3723.25},
+		{in: "02:03.5", want: 123.5},
+		{in: "0:00:05.00", want: 5},
+		{in: "5", wantErr: true},
+		{in: "1:2:3:4", wantErr: true},
+		{in: "00:-1:00", wantErr: true},
+		{in: "aa:bb", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseTimestamp(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSubtitles(t *testing.T) {
+	tests := []struct {
+		name     string
+		parse    func(string) (*models.TranscriptionResult, error)
+		input    string
+		want     []models.Segment
+		speakers map[string]string
+		wantErr  bool
+	}{
+		{
+			name:  "srt",
+			parse: parseString(parseSRT),
+			input: "\ufeff1\r\n00:00:01,000 --> 00:00:02,500\r\nHello <i>there</i>\r\n\r\n" +
+				"2\n00:00:03,000 --> 00:00:04,000\nTwo\nlines\n\n" +
+				"3\n00:00:05,000 --> 00:00:06,000\n<b></b>\n",
+			want: []models.Segment{
+				{Start: 1, End: 2.5, Text: "Hello there"},
+				{Start: 3, End: 4, Text: "Two\nlines"},
+			},
+		},
+		{
+			name:    "srt bad timestamp",
+			parse:   parseString(parseSRT),
+			input:   "1\n00:00:xx,000 --> 00:00:02,000\nHi\n",
+			wantErr: true,
+		},
+		{
+			name:  "vtt",
+			parse: parseString(parseVTT),
+			input: "WEBVTT\n\nNOTE a comment\n\n" +
+				"intro\n00:01.000 --> 00:02.000 align:start\n<v Alice>Hi &amp; welcome\n\n" +
+				"00:02.000 --> 00:03.000\n<v.loud Bob>Hello\n\n" +
+				"00:03.000 --> 00:04.000\n<v Alice>Again\n",
+			want: []models.Segment{
+				{Start: 1, End: 2, Text: "Hi & welcome", Speaker: "S1"},
+				{Start: 2, End: 3, Text: "Hello", Speaker: "S2"},
+				{Start: 3, End: 4, Text: "Again", Speaker: "S1"},
+			},
+			speakers: map[string]string{"S1": "Alice", "S2": "Bob"},
+		},
+		{
+			name:    "vtt without header",
+			parse:   parseString(parseVTT),
+			input:   "00:01.000 --> 00:02.000\nHi\n",
+			wantErr: true,
+		},
+		{
+			name:  "ass",
+			parse: parseString(parseASS),
+			input: "[Script Info]\nTitle: test\n\n[Events]\n" +
+				"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+				"Dialogue: 0,0:00:01.00,0:00:02.50,Default,Ann,0,0,0,,{\\i1}Hi,{\\i0} there\\Nfriend\n" +
+				"Comment: 0,0:00:02.00,0:00:03.00,Default,,0,0,0,,skipped\n" +
+				"Dialogue: 0,0:00:03.00,0:00:04.00,Default,,0,0,0,,No\\hname\n",
+			want: []models.Segment{
+				{Start: 1, End: 2.5, Text: "Hi, there\nfriend", Speaker: "S1"},
+				{Start: 3, End: 4, Text: "No name"},
+			},
+			speakers: map[string]string{"S1": "Ann"},
+		},
+		{
+			name:    "ass dialogue before format",
+			parse:   parseString(parseASS),
+			input:   "[Events]\nDialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,Hi\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parse(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Segments) != len(tt.want) {
+				t.Fatalf("got %d segments %+v, want %d", len(got.Segments), got.Segments, len(tt.want))
+			}
+			for i, want := range tt.want {
+				seg := got.Segments[i]
+				if seg.Start != want.Start || seg.End != want.End || seg.Text != want.Text || seg.Speaker != want.Speaker {
+					t.Errorf("segment %d: got %+v, want %+v", i, seg, want)
+				}
+			}
+			if !maps.Equal(got.Speakers, tt.speakers) {
+				t.Errorf("speakers %v, want %v", got.Speakers, tt.speakers)
+			}
+		})
+	}
+}
+
+func parseString(parse func(io.Reader) (*models.TranscriptionResult, error)) func(string) (*models.TranscriptionResult, error) {
+	return func(s string) (*models.TranscriptionResult, error) {
+		return parse(strings.NewReader(s))
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// PauseGate blocks callers of Wait while paused.
+type PauseGate struct {
+	mu      sync.Mutex
+	resumed chan struct{}
+}
+
+func NewPauseGate() *PauseGate {
+	return &PauseGate{}
+}
+
+func (g *PauseGate) Pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed == nil {
+		g.resumed = make(chan struct{})
+	}
+}
+
+func (g *PauseGate) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.resumed != nil {
+		close(g.resumed)
+		g.resumed = nil
+	}
+}
+
+func (g *PauseGate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.resumed != nil
+}
+
+func (g *PauseGate) Wait(ctx context.Context) error {
+	g.mu.Lock()
+	resumed := g.resumed
+	g.mu.Unlock()
+	if resumed == nil {
+		return nil
+	}
+	select {
+	case <-resumed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"whisper-transcriber/pkg/models"
+)
+
+var preprocessPresets = map[string]models.PreprocessConfig{
+	"none": {},
+	"speech": {
+		Normalize:  true,
+		HighPassHz: 80,
+		LowPassHz:  8000,
+	},
+	"noisy": {
+		Normalize:  true,
+		HighPassHz: 100,
+		LowPassHz:  7000,
+		Denoise:    "afftdn",
+	},
+}
+
+func PreprocessPresets() []string {
+	names := make([]string, 0, len(preprocessPresets))
+	for name := range preprocessPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolvePreprocess expands a named preset into its settings.
+func resolvePreprocess(cfg models.PreprocessConfig) (models.PreprocessConfig, error) {
+	if cfg.Preset == "" || cfg.Preset == "custom" {
+		return cfg, nil
+	}
+	preset, ok := preprocessPresets[cfg.Preset]
+	if !ok {
+		return cfg, fmt.Errorf("unknown preprocessing preset: %s", cfg.Preset)
+	}
+	return preset, nil
+}
+
+// PreprocessFilters resolves the config (expanding a named preset) into an
+// ffmpeg audio filter chain. Silence trimming is not a filter: it is done on
+// the samples so timestamps can be mapped back to the source.
+func PreprocessFilters(cfg models.PreprocessConfig) ([]string, error) {
+	cfg, err := resolvePreprocess(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var filters []string
+	if cfg.HighPassHz > 0 {
+		filters = append(filters, fmt.Sprintf("highpass=f=%d", cfg.HighPassHz))
+	}
+	if cfg.LowPassHz > 0 {
+		filters = append(filters, fmt.Sprintf("lowpass=f=%d", cfg.LowPassHz))
+	}
+
+	switch cfg.Denoise {
+	case "":
+	case "afftdn":
+		filters = append(filters, "afftdn=nf=-25")
+	case "arnndn":
+		if cfg.DenoiseModel == "" {
+			return nil, fmt.Errorf("arnndn requires a model file")
+		}
+		filters = append(filters, fmt.Sprintf("arnndn=m='%s'", escapeFilterPath(cfg.DenoiseModel)))
+	default:
+		return nil, fmt.Errorf("unknown denoise filter: %s", cfg.Denoise)
+	}
+
+	// Loudness normalisation goes last so it sees the filtered signal.
+	if cfg.Normalize {
+		filters = append(filters, "loudnorm=I=-16:TP=-1.5:LRA=11")
+	}
+	return filters, nil
+}
+
+// trimsSilence reports whether cfg, after preset expansion, trims leading and
+// trailing silence.
+func trimsSilence(cfg models.PreprocessConfig) bool {
+	cfg, err := resolvePreprocess(cfg)
+	return err == nil && cfg.TrimSilence
+}
+
+func escapeFilterPath(path string) string {
+	path = strings.ReplaceAll(path, `\`, "/")
+	path = strings.ReplaceAll(path, ":", `\:`)
+	return strings.ReplaceAll(path, "'", `\'`)
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"slices"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestPreprocessFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     models.PreprocessConfig
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty"},
+		{name: "none preset", cfg: models.PreprocessConfig{Preset: "none", Normalize: true}},
+		{
+			name: "speech preset",
+			cfg:  models.PreprocessConfig{Preset: "speech"},
+			want: []string{"highpass=f=80", "lowpass=f=8000", "loudnorm=I=-16:TP=-1.5:LRA=11"},
+		},
+		{
+			name: "noisy preset",
+			cfg:  models.PreprocessConfig{Preset: "noisy"},
+			want: []string{"highpass=f=100", "lowpass=f=7000", "afftdn=nf=-25", "loudnorm=I=-16:TP=-1.5:LRA=11"},
+		},
+		{
+			name: "custom keeps its own settings",
+			cfg:  models.PreprocessConfig{Preset: "custom", LowPassHz: 4000},
+			want: []string{"lowpass=f=4000"},
+		},
+		{
+			name: "arnndn escapes the model path",
+			cfg:  models.PreprocessConfig{Denoise: "arnndn", DenoiseModel: `C:\models\it's.rnnn`},
+			want: []string{`arnndn=m='C\:/models/it\'s.rnnn'`},
+		},
+		{name: "trim silence is not a filter", cfg: models.PreprocessConfig{TrimSilence: true}},
+		{name: "arnndn without model", cfg: models.PreprocessConfig{Denoise: "arnndn"}, wantErr: true},
+		{name: "unknown denoise", cfg: models.PreprocessConfig{Denoise: "magic"}, wantErr: true},
+		{name: "unknown preset", cfg: models.PreprocessConfig{Preset: "studio"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PreprocessFilters(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"whisper-transcriber/pkg/models"
+)
+
+type ProbeSvc struct {
+	appDir string
+}
+
+func NewProbeService(appDir string) *ProbeSvc {
+	return &ProbeSvc{appDir: appDir}
+}
+
+func (s *ProbeSvc) localPath() string {
+	name := "ffprobe"
+	if runtime.GOOS == "windows" {
+		name = "ffprobe.exe"
+	}
+	return filepath.Join(s.appDir, name)
+}
+
+func (s *ProbeSvc) binPath() (string, error) {
+	bundled := s.localPath()
+	if _, err := os.Stat(bundled); err == nil {
+		return bundled, nil
+	}
+	p, err := exec.LookPath("ffprobe")
+	if err != nil {
+		return "", models.ErrFFprobeNotFound
+	}
+	return p, nil
+}
+
+func (s *ProbeSvc) IsAvailable() bool {
+	_, err := s.binPath()
+	return err == nil
+}
+
+type ffprobeOutput struct {
+	Streams []struct {
+		Index         int               `json:"index"`
+		CodecName     string            `json:"codec_name"`
+		CodecType     string            `json:"codec_type"`
+		SampleRate    string            `json:"sample_rate"`
+		Channels      int               `json:"channels"`
+		ChannelLayout string            `json:"channel_layout"`
+		Disposition   map[string]int    `json:"disposition"`
+		Tags          map[string]string `json:"tags"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string            `json:"format_name"`
+		Duration   string            `json:"duration"`
+		Tags       map[string]string `json:"tags"`
+	} `json:"format"`
+}
+
+func (s *ProbeSvc) Probe(ctx context.Context, path string) (*models.MediaInfo, error) {
+	fp, err := s.binPath()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, fp,
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		path,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("ffprobe cancelled: %w", ctx.Err())
+		}
+		var stderr string
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr = strings.TrimSpace(string(exitErr.Stderr))
+		}
+		return nil, toolError(err, stderr, models.CodeUnsupportedMedia, "unsupported media")
+	}
+
+	return parseFFprobeOutput(output)
+}
+
+func parseFFprobeOutput(data []byte) (*models.MediaInfo, error) {
+	var out ffprobeOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid ffprobe output: %w", err)
+	}
+
+	duration, _ := strconv.ParseFloat(out.Format.Duration, 64)
+	info := &models.MediaInfo{
+		Duration:  duration,
+		Container: out.Format.FormatName,
+		Tags:      out.Format.Tags,
+	}
+
+	for _, st := range out.Streams {
+		switch st.CodecType {
+		case "video":
+			if info.VideoCodec == "" {
+				info.VideoCodec = st.CodecName
+			}
+		case "audio":
+			sampleRate, _ := strconv.Atoi(st.SampleRate)
+			info.AudioStreams = append(info.AudioStreams, models.AudioStream{
+				Index:         st.Index,
+				Codec:         st.CodecName,
+				SampleRate:    sampleRate,
+				Channels:      st.Channels,
+				ChannelLayout: st.ChannelLayout,
+				Language:      st.Tags["language"],
+				Title:         st.Tags["title"],
+				Default:       st.Disposition["default"] == 1,
+			})
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestParseFFprobeOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *models.MediaInfo
+		wantErr bool
+	}{
+		{
+			name: "video with two audio tracks",
+			data: `{
+				"streams": [
+					{"index": 0, "codec_name": "h264", "codec_type": "video"},
+					{"index": 1, "codec_name": "aac", "codec_type": "audio", "sample_rate": "48000", "channels": 2,
+					 "channel_layout": "stereo", "disposition": {"default": 1}, "tags": {"language": "eng", "title": "Main"}},
+					{"index": 2, "codec_name": "ac3", "codec_type": "audio", "sample_rate": "44100", "channels": 6,
+					 "channel_layout": "5.1", "disposition": {"default": 0}, "tags": {"language": "ger"}},
+					{"index": 3, "codec_name": "subrip", "codec_type": "subtitle"},
+					{"index": 4, "codec_name": "mjpeg", "codec_type": "video"}
+				],
+				"format": {"format_name": "matroska,webm", "duration": "125.500000", "tags": {"title": "Talk"}}
+			}`,
+			want: &models.MediaInfo{
+				Duration:   125.5,
+				Container:  "matroska,webm",
+				VideoCodec: "h264",
+				Tags:       map[string]string{"title": "Talk"},
+				AudioStreams: []models.AudioStream{
+					{Index: 1, Codec: "aac", SampleRate: 48000, Channels: 2, ChannelLayout: "stereo", Language: "eng", Title: "Main", Default: true},
+					{Index: 2, Codec: "ac3", SampleRate: 44100, Channels: 6, ChannelLayout: "5.1", Language: "ger"},
+				},
+			},
+		},
+		{
+			name: "audio only without duration",
+			data: `{"streams": [{"index": 0, "codec_name": "mp3", "codec_type": "audio", "sample_rate": "bad", "channels": 1}],
+				"format": {"format_name": "mp3", "duration": "N/A"}}`,
+			want: &models.MediaInfo{
+				Container:    "mp3",
+				AudioStreams: []models.AudioStream{{Index: 0, Codec: "mp3", Channels: 1}},
+			},
+		},
+		{
+			name: "no audio",
+			data: `{"streams": [{"index": 0, "codec_name": "png", "codec_type": "video"}], "format": {"format_name": "image2"}}`,
+			want: &models.MediaInfo{Container: "image2", VideoCodec: "png"},
+		},
+		{name: "invalid json", data: `{"streams": [`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFFprobeOutput([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
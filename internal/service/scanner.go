@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"whisper-transcriber/pkg/models"
+)
+
+var (
+	VideoExtensions = []string{".mp4", ".mkv", ".avi", ".mov", ".webm"}
+	AudioExtensions = []string{".wav", ".mp3", ".flac", ".ogg", ".m4a"}
+)
+
+func MediaExtensions() []string {
+	return append(append([]string(nil), VideoExtensions...), AudioExtensions...)
+}
+
+// scanFilter applies ScanOptions to paths under root. Include and exclude
+// globs are matched against both the file name and the path relative to root.
+type scanFilter struct {
+	root string
+	opts models.ScanOptions
+	exts map[string]bool
+}
+
+func newScanFilter(root string, opts models.ScanOptions) *scanFilter {
+	exts := opts.Extensions
+	if len(exts) == 0 {
+		exts = MediaExtensions()
+	}
+	extSet := make(map[string]bool, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		extSet[ext] = true
+	}
+	return &scanFilter{root: root, opts: opts, exts: extSet}
+}
+
+// skipDir reports whether the walk should not descend into dir.
+func (f *scanFilter) skipDir(dir string) bool {
+	if dir == f.root {
+		return false
+	}
+	name := filepath.Base(dir)
+	if !f.opts.IncludeHidden && strings.HasPrefix(name, ".") {
+		return true
+	}
+	return !f.opts.Recursive || matchesAny(f.opts.Exclude, name, f.rel(dir))
+}
+
+func (f *scanFilter) acceptFile(path string) bool {
+	name := filepath.Base(path)
+	rel := f.rel(path)
+	if !f.opts.IncludeHidden && strings.HasPrefix(name, ".") {
+		return false
+	}
+	if !f.exts[strings.ToLower(filepath.Ext(path))] {
+		return false
+	}
+	if len(f.opts.Include) > 0 && !matchesAny(f.opts.Include, name, rel) {
+		return false
+	}
+	if matchesAny(f.opts.Exclude, name, rel) {
+		return false
+	}
+	if f.opts.SkipTranscribed != "" && outputExists(path, f.opts.OutputDir, f.opts.SkipTranscribed) {
+		return false
+	}
+	return true
+}
+
+// skipNested reports whether dir or any folder between it and root is
+// skipped. It checks paths found outside a walk, such as watcher events.
+func (f *scanFilter) skipNested(dir string) bool {
+	for ; dir != f.root && strings.HasPrefix(dir, f.root); dir = filepath.Dir(dir) {
+		if f.skipDir(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *scanFilter) acceptNested(path string) bool {
+	return !f.skipNested(filepath.Dir(path)) && f.acceptFile(path)
+}
+
+func (f *scanFilter) rel(path string) string {
+	rel, _ := filepath.Rel(f.root, path)
+	return filepath.ToSlash(rel)
+}
+
+// ScanFolder lists media files under root that pass the scan options.
+func ScanFolder(ctx context.Context, root string, opts models.ScanOptions) ([]string, error) {
+	filter := newScanFilter(root, opts)
+
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			// Unreadable entries are skipped rather than aborting the scan.
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if d.IsDir() {
+			if filter.skipDir(path) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if filter.acceptFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+func matchesAny(patterns []string, name, rel string) bool {
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(filepath.ToSlash(p), rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// outputExists looks for the output where a batch writes it: next to the
+// source, or in outputDir when one is set.
+func outputExists(sourcePath, outputDir, format string) bool {
+	if outputDir != "" {
+		sourcePath = filepath.Join(outputDir, filepath.Base(sourcePath))
+	}
+	base := strings.TrimSuffix(sourcePath, filepath.Ext(sourcePath))
+	_, err := os.Stat(base + "." + format)
+	return err == nil
+}
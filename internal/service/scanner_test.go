@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestScanFolder(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"a.mp4", "b.WAV", "notes.txt", ".hidden.mp3",
+		"done.mkv", "done.srt",
+		"sub/c.mp3", "sub/skip-me.mp3", "sub/deep/d.flac",
+		"drafts/e.wav", ".cache/f.wav",
+		"out/g.srt", "g.mp4",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		opts models.ScanOptions
+		want []string
+	}{
+		{
+			name: "top level only",
+			want: []string{"a.mp4", "b.WAV", "done.mkv", "g.mp4"},
+		},
+		{
+			name: "recursive",
+			opts: models.ScanOptions{Recursive: true},
+			want: []string{"a.mp4", "b.WAV", "done.mkv", "drafts/e.wav", "g.mp4", "sub/c.mp3", "sub/deep/d.flac", "sub/skip-me.mp3"},
+		},
+		{
+			name: "hidden files and folders",
+			opts: models.ScanOptions{Recursive: true, IncludeHidden: true, Include: []string{"*.mp3", "*.wav"}},
+			want: []string{".cache/f.wav", ".hidden.mp3", "drafts/e.wav", "sub/c.mp3", "sub/skip-me.mp3"},
+		},
+		{
+			name: "extensions without dots",
+			opts: models.ScanOptions{Recursive: true, Extensions: []string{"FLAC", ".mp4"}},
+			want: []string{"a.mp4", "g.mp4", "sub/deep/d.flac"},
+		},
+		{
+			name: "exclude by name and folder",
+			opts: models.ScanOptions{Recursive: true, Exclude: []string{"skip-*", "drafts", "sub/deep"}},
+			want: []string{"a.mp4", "b.WAV", "done.mkv", "g.mp4", "sub/c.mp3"},
+		},
+		{
+			name: "include by relative path",
+			opts: models.ScanOptions{Recursive: true, Include: []string{"sub/*"}},
+			want: []string{"sub/c.mp3", "sub/skip-me.mp3"},
+		},
+		{
+			name: "skip transcribed",
+			opts: models.ScanOptions{SkipTranscribed: "srt"},
+			want: []string{"a.mp4", "b.WAV", "g.mp4"},
+		},
+		{
+			name: "skip transcribed in the output folder",
+			opts: models.ScanOptions{SkipTranscribed: "srt", OutputDir: filepath.Join(root, "out")},
+			want: []string{"a.mp4", "b.WAV", "done.mkv"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := ScanFolder(context.Background(), root, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range paths {
+				rel, _ := filepath.Rel(root, p)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ScanFolder(context.Background(), filepath.Join(root, "missing"), models.ScanOptions{}); err == nil {
+		t.Error("missing root: want error")
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"sync"
+
+	"whisper-transcriber/internal/infrastructure"
+)
+
+// maxThroughputAudioSec bounds how much audio the throughput average
+// remembers, so it follows changes in hardware and settings.
+const maxThroughputAudioSec = 4 * 3600
+
+type throughputStat struct {
+	AudioSec float64 `json:"audioSec"`
+	WallSec  float64 `json:"wallSec"`
+}
+
+type settingsData struct {
+	Throughput map[string]throughputStat `json:"throughput"`
+}
+
+// Settings persists values the app learns as it runs.
+type Settings struct {
+	mu   sync.Mutex
+	path string
+	data settingsData
+}
+
+func NewSettings(path string) *Settings {
+	s := &Settings{path: path}
+	_ = infrastructure.ReadJSON(path, &s.data)
+	return s
+}
+
+func (s *Settings) Throughput(model string) (float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stat, ok := s.data.Throughput[model]
+	if !ok || stat.WallSec <= 0 {
+		return 0, false
+	}
+	return stat.AudioSec / stat.WallSec, true
+}
+
+// RecordThroughput adds a measurement for model. Once the history exceeds
+// maxThroughputAudioSec it is scaled down, so older runs count for less.
+func (s *Settings) RecordThroughput(model string, audioSec, wallSec float64) error {
+	if audioSec <= 0 || wallSec <= 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Throughput == nil {
+		s.data.Throughput = make(map[string]throughputStat)
+	}
+	stat := s.data.Throughput[model]
+	stat.AudioSec += audioSec
+	stat.WallSec += wallSec
+	if stat.AudioSec > maxThroughputAudioSec {
+		scale := maxThroughputAudioSec / stat.AudioSec
+		stat.AudioSec *= scale
+		stat.WallSec *= scale
+	}
+	s.data.Throughput[model] = stat
+	return infrastructure.WriteJSON(s.path, s.data)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"whisper-transcriber/pkg/models"
+)
+
+// normalizeRanges sorts ranges and rejects empty or overlapping ones.
+// An End of zero means "until the end of the file" and is only allowed on the
+// last range.
+func normalizeRanges(ranges []models.TimeRange) ([]models.TimeRange, error) {
+	out := append([]models.TimeRange(nil), ranges...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+
+	for i, r := range out {
+		if r.Start < 0 {
+			return nil, fmt.Errorf("range %d: negative start", i+1)
+		}
+		if r.End == 0 && i != len(out)-1 {
+			return nil, fmt.Errorf("range %d: open-ended range must be the last one", i+1)
+		}
+		if r.End != 0 && r.End <= r.Start {
+			return nil, fmt.Errorf("range %d: end must be after start", i+1)
+		}
+		if i > 0 && r.Start < out[i-1].End {
+			return nil, fmt.Errorf("range %d overlaps range %d", i+1, i)
+		}
+	}
+	return out, nil
+}
+
+// selectedDuration returns the total length of the ranges, clipped to the
+// media duration when it is known.
+func selectedDuration(ranges []models.TimeRange, duration float64) float64 {
+	if len(ranges) == 0 {
+		return duration
+	}
+	var total float64
+	for _, r := range ranges {
+		end := r.End
+		if end == 0 || (duration > 0 && end > duration) {
+			end = duration
+		}
+		if end > r.Start {
+			total += end - r.Start
+		}
+	}
+	return total
+}
+
+// toOriginalTime maps a timestamp on the concatenated timeline of the given
+// ranges back onto the source file's timeline. A time on the boundary
+// between two ranges is the start of the later one, or the end of the
+// earlier one if isEnd is set.
+func toOriginalTime(t float64, ranges []models.TimeRange, isEnd bool) float64 {
+	var offset float64
+	for i, r := range ranges {
+		length := r.End - r.Start
+		inRange := t < offset+length
+		if isEnd {
+			inRange = t <= offset+length
+		}
+		if r.End == 0 || i == len(ranges)-1 || inRange {
+			return r.Start + t - offset
+		}
+		offset += length
+	}
+	return t
+}
+
+func remapSegments(segments []models.Segment, ranges []models.TimeRange) []models.Segment {
+	if len(ranges) == 0 {
+		return segments
+	}
+	for i := range segments {
+		segments[i].Start = toOriginalTime(segments[i].Start, ranges, false)
+		segments[i].End = toOriginalTime(segments[i].End, ranges, true)
+	}
+	return segments
+}
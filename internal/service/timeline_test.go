@@ -0,0 +1,125 @@
+package service
+
+import (
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestNormalizeRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []models.TimeRange
+		want    []models.TimeRange
+		wantErr bool
+	}{
+		{name: "empty", in: nil, want: []models.TimeRange{}},
+		{
+			name: "sorted by start",
+			in:   []models.TimeRange{{Start: 30, End: 40}, {Start: 0, End: 10}},
+			want: []models.TimeRange{{Start: 0, End: 10}, {Start: 30, End: 40}},
+		},
+		{
+			name: "open end last",
+			in:   []models.TimeRange{{Start: 50}, {Start: 0, End: 10}},
+			want: []models.TimeRange{{Start: 0, End: 10}, {Start: 50}},
+		},
+		{
+			name: "touching ranges",
+			in:   []models.TimeRange{{Start: 0, End: 10}, {Start: 10, End: 20}},
+			want: []models.TimeRange{{Start: 0, End: 10}, {Start: 10, End: 20}},
+		},
+		{name: "negative start", in: []models.TimeRange{{Start: -1, End: 5}}, wantErr: true},
+		{name: "end before start", in: []models.TimeRange{{Start: 5, End: 5}}, wantErr: true},
+		{name: "open end not last", in: []models.TimeRange{{Start: 0}, {Start: 20, End: 30}}, wantErr: true},
+		{name: "overlap", in: []models.TimeRange{{Start: 0, End: 15}, {Start: 10, End: 20}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeRanges(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectedDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		ranges   []models.TimeRange
+		duration float64
+		want     float64
+	}{
+		{name: "no ranges", duration: 90, want: 90},
+		{name: "closed ranges", ranges: []models.TimeRange{{Start: 0, End: 10}, {Start: 20, End: 25}}, duration: 90, want: 15},
+		{name: "open end", ranges: []models.TimeRange{{Start: 60}}, duration: 90, want: 30},
+		{name: "clipped to duration", ranges: []models.TimeRange{{Start: 80, End: 120}}, duration: 90, want: 10},
+		{name: "unknown duration", ranges: []models.TimeRange{{Start: 0, End: 10}, {Start: 60}}, want: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectedDuration(tt.ranges, tt.duration); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToOriginalTime(t *testing.T) {
+	ranges := []models.TimeRange{{Start: 10, End: 20}, {Start: 50, End: 60}, {Start: 100}}
+	tests := []struct {
+		name  string
+		t     float64
+		isEnd bool
+		want  float64
+	}{
+		{name: "first range", t: 5, want: 15},
+		{name: "second range", t: 15, want: 55},
+		{name: "open last range", t: 25, want: 105},
+		{name: "start on boundary", t: 10, want: 50},
+		{name: "end on boundary", t: 10, isEnd: true, want: 20},
+		{name: "end inside range", t: 15, isEnd: true, want: 55},
+		{name: "end on second boundary", t: 20, isEnd: true, want: 60},
+		{name: "past the ranges", t: 40, want: 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toOriginalTime(tt.t, ranges, tt.isEnd); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemapSegments(t *testing.T) {
+	ranges := []models.TimeRange{{Start: 10, End: 20}, {Start: 50, End: 60}}
+	segments := []models.Segment{
+		{Start: 0, End: 10},
+		{Start: 10, End: 14},
+	}
+	got := remapSegments(segments, ranges)
+	want := []models.Segment{
+		{Start: 10, End: 20},
+		{Start: 50, End: 54},
+	}
+	for i := range want {
+		if got[i].Start != want[i].Start || got[i].End != want[i].End {
+			t.Errorf("segment %d: got %v-%v, want %v-%v", i, got[i].Start, got[i].End, want[i].Start, want[i].End)
+		}
+	}
+}
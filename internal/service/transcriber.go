@@ -5,8 +5,12 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	whisper "github.com/ggerganov/whisper.cpp/bindings/go/pkg/whisper"
 
@@ -14,54 +18,126 @@ import (
 )
 
 type WhisperTranscriber struct {
-	model whisper.Model
-	mu    sync.Mutex
+	mu        sync.Mutex
+	modelPath string
+	instances []whisper.Model
+	// pool hands out idle instances; a whisper context is not safe for
+	// concurrent use, so each worker needs its own loaded model.
+	pool chan whisper.Model
 }
 
 func NewTranscriber() *WhisperTranscriber {
-	return &WhisperTranscriber{}
+	return &WhisperTranscriber{pool: make(chan whisper.Model, maxWorkers)}
 }
 
 func (t *WhisperTranscriber) LoadModel(modelPath string) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if t.model != nil {
-		t.model.Close()
-	}
+	t.closeInstances()
 
+	start := time.Now()
 	model, err := whisper.New(modelPath)
 	if err != nil {
-		return fmt.Errorf("failed to load model: %w", err)
+		slog.Error("model load failed", "model", filepath.Base(modelPath), "err", err)
+		return models.NewError(models.CodeModelCorrupt, "failed to load model; it may be damaged, download it again", err)
 	}
-	t.model = model
+	slog.Info("model loaded", "model", filepath.Base(modelPath), "duration", time.Since(start).Round(time.Millisecond))
+	t.modelPath = modelPath
+	t.instances = []whisper.Model{model}
+	t.pool <- model
+	return nil
+}
+
+// SetConcurrency loads or releases model instances so that n files can be
+// transcribed at once. It must not be called while a batch is running.
+func (t *WhisperTranscriber) SetConcurrency(n int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.instances) == 0 {
+		return models.ErrModelNotLoaded
+	}
+	n = clampWorkers(n)
+
+	for len(t.instances) < n {
+		model, err := whisper.New(t.modelPath)
+		if err != nil {
+			// The first instance loaded, so another copy failing is almost
+			// always a lack of memory.
+			return models.NewError(models.CodeOutOfMemory, "not enough memory for another model instance; lower the worker count", err)
+		}
+		t.instances = append(t.instances, model)
+		t.pool <- model
+	}
+	for len(t.instances) > n {
+		model := <-t.pool
+		model.Close()
+		t.instances = removeModel(t.instances, model)
+	}
+	slog.Debug("model instances", "count", n)
 	return nil
 }
 
 func (t *WhisperTranscriber) IsLoaded() bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	return t.model != nil
+	return len(t.instances) > 0
+}
+
+func (t *WhisperTranscriber) ModelPath() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.modelPath
 }
 
 func (t *WhisperTranscriber) TranscribeFile(
 	ctx context.Context,
-	fileID, audioPath, language string,
+	fileID, audioPath string,
+	opts models.TranscribeOptions,
 	onProgress models.ProgressFunc,
 ) (*models.TranscriptionResult, error) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	if t.model == nil {
+	if !t.IsLoaded() {
 		return nil, models.ErrModelNotLoaded
 	}
 
+	var model whisper.Model
+	select {
+	case model = <-t.pool:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { t.pool <- model }()
+
 	samples, err := readWavSamples(audioPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read audio: %w", err)
 	}
 
-	wCtx, err := t.model.NewContext()
+	language := opts.Language
+
+	// Both VAD and trimming transcribe only part of the audio; segments are
+	// mapped back through speech afterwards. VAD already drops the edges.
+	var speech []models.TimeRange
+	switch {
+	case opts.VAD.Enabled:
+		speech = detectSpeech(samples, whisper.SampleRate, opts.VAD)
+	case opts.TrimSilence:
+		if r, ok := trimmedRange(samples, whisper.SampleRate); ok {
+			speech = []models.TimeRange{r}
+		}
+	}
+	if opts.VAD.Enabled || opts.TrimSilence {
+		if len(speech) == 0 {
+			return &models.TranscriptionResult{
+				FilePath: audioPath,
+				Language: language,
+			}, nil
+		}
+		samples = speechSamples(samples, whisper.SampleRate, speech)
+	}
+
+	wCtx, err := model.NewContext()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create context: %w", err)
 	}
@@ -71,10 +147,18 @@ func (t *WhisperTranscriber) TranscribeFile(
 			_ = wCtx.SetLanguage("auto")
 		}
 	}
+	if opts.Threads > 0 {
+		wCtx.SetThreads(uint(opts.Threads))
+	}
 
 	cancelled := false
 	if err := wCtx.Process(samples,
 		func() bool {
+			// Called before each window is encoded, so pausing here
+			// suspends the transcription between windows.
+			if opts.Gate != nil {
+				_ = opts.Gate.Wait(ctx)
+			}
 			select {
 			case <-ctx.Done():
 				cancelled = true
@@ -106,54 +190,200 @@ func (t *WhisperTranscriber) TranscribeFile(
 			return nil, fmt.Errorf("error reading segment: %w", err)
 		}
 		segments = append(segments, models.Segment{
-			Index: seg.Num,
-			Start: seg.Start.Seconds(),
-			End:   seg.End.Seconds(),
-			Text:  seg.Text,
+			Index:      seg.Num,
+			Start:      seg.Start.Seconds(),
+			End:        seg.End.Seconds(),
+			Text:       seg.Text,
+			Confidence: segmentConfidence(wCtx, seg),
+		})
+	}
+
+	var report *models.CleanupReport
+	if opts.Cleanup != "" && opts.Cleanup != "off" {
+		segments, report = cleanSegments(segments, opts.Cleanup, cleanupFuncs{
+			level: func(seg models.Segment) float64 {
+				return segmentLevel(samples, whisper.SampleRate, seg)
+			},
+			redecode: func(seg models.Segment) (string, error) {
+				return redecode(ctx, model, samples, seg, language)
+			},
 		})
 	}
 
+	var speakers map[string]string
+	if opts.Diarization.Enabled {
+		segments, speakers = diarizeSegments(segments, samples, whisper.SampleRate, opts.Diarization.Speakers)
+	}
+
 	return &models.TranscriptionResult{
 		FilePath: audioPath,
 		Language: language,
-		Segments: segments,
+		Cleanup:  report,
+		Speakers: speakers,
+		Segments: remapSegments(segments, speech),
 	}, nil
 }
 
+// redecode transcribes a single segment's audio again at a higher temperature.
+func redecode(ctx context.Context, model whisper.Model, samples []float32, seg models.Segment, language string) (string, error) {
+	from := int(seg.Start * whisper.SampleRate)
+	to := int(seg.End * whisper.SampleRate)
+	if to > len(samples) {
+		to = len(samples)
+	}
+	if from >= to {
+		return "", nil
+	}
+	clip := append([]float32(nil), samples[from:to]...)
+	// whisper.cpp rejects inputs shorter than a second.
+	if minLen := int(minRedecodeAudio * whisper.SampleRate); len(clip) < minLen {
+		clip = append(clip, make([]float32, minLen-len(clip))...)
+	}
+
+	wCtx, err := model.NewContext()
+	if err != nil {
+		return "", fmt.Errorf("failed to create context: %w", err)
+	}
+	if language != "" && language != "auto" {
+		_ = wCtx.SetLanguage(language)
+	}
+	wCtx.SetTemperature(redecodeTemp)
+	wCtx.SetMaxContext(0)
+
+	keepGoing := func() bool { return ctx.Err() == nil }
+	if err := wCtx.Process(clip, keepGoing, nil, nil); err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for {
+		s, err := wCtx.NextSegment()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(s.Text)
+	}
+	return sb.String(), nil
+}
+
+func segmentConfidence(wCtx whisper.Context, seg whisper.Segment) float64 {
+	var sum float64
+	var n int
+	for _, tok := range seg.Tokens {
+		if wCtx.IsText(tok) {
+			sum += float64(tok.P)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
 func (t *WhisperTranscriber) Close() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	if t.model != nil {
-		t.model.Close()
-		t.model = nil
+	t.closeInstances()
+}
+
+// closeInstances waits for every instance to be returned to the pool, so it
+// blocks until in-flight transcriptions finish. The caller must hold t.mu.
+func (t *WhisperTranscriber) closeInstances() {
+	for range t.instances {
+		model := <-t.pool
+		model.Close()
 	}
+	t.instances = nil
 }
 
-func readWavSamples(path string) ([]float32, error) {
+func removeModel(instances []whisper.Model, model whisper.Model) []whisper.Model {
+	for i, m := range instances {
+		if m == model {
+			return append(instances[:i], instances[i+1:]...)
+		}
+	}
+	return instances
+}
+
+// wavDuration returns the length in seconds of a WAV written by ExtractAudio
+// (16 kHz mono 16-bit PCM).
+func wavDuration(path string) float64 {
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return 0
 	}
 	defer f.Close()
+	dataSize, err := wavData(f)
+	if err != nil {
+		return 0
+	}
+	return float64(dataSize) / 2 / whisper.SampleRate
+}
 
-	header := make([]byte, 44)
-	if _, err := io.ReadFull(f, header); err != nil {
-		return nil, fmt.Errorf("invalid WAV header: %w", err)
+// wavData walks the RIFF chunks to the "data" chunk, since ffmpeg may write
+// LIST or fact chunks before it, and leaves f at the first sample. The size
+// falls back to what the file holds when the header was never finalised and
+// declares 0 or more than was written.
+func wavData(f *os.File) (int64, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(f, header[:]); err != nil {
+		return 0, fmt.Errorf("invalid WAV header: %w", err)
+	}
+	if string(header[:4]) != "RIFF" || string(header[8:]) != "WAVE" {
+		return 0, fmt.Errorf("invalid WAV header: not a RIFF/WAVE file")
 	}
 
-	fi, err := f.Stat()
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(f, chunk[:]); err != nil {
+			return 0, fmt.Errorf("invalid WAV header: no data chunk: %w", err)
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		if string(chunk[:4]) != "data" {
+			// Chunks are padded to an even length.
+			if _, err := f.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return 0, fmt.Errorf("invalid WAV header: %w", err)
+			}
+			continue
+		}
+
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, err
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			return 0, fmt.Errorf("failed to stat file: %w", err)
+		}
+		available := max(fi.Size()-offset, 0)
+		if size == 0 || size > available {
+			size = available
+		}
+		return size, nil
+	}
+}
+
+func readWavSamples(path string) ([]float32, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to stat file: %w", err)
+		return nil, err
 	}
-	dataSize := fi.Size() - 44
-	if dataSize < 0 {
-		dataSize = 0
+	defer f.Close()
+
+	dataSize, err := wavData(f)
+	if err != nil {
+		return nil, err
 	}
 	n := dataSize / 2
 	samples := make([]float32, 0, n)
+	r := io.LimitReader(f, n*2)
 	for {
 		var sample int16
-		err := binary.Read(f, binary.LittleEndian, &sample)
+		err := binary.Read(r, binary.LittleEndian, &sample)
 		if err == io.EOF {
 			break
 		}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeWav writes a RIFF/WAVE file with the given chunks, each a four-letter
+// id followed by its payload. dataSize overrides the declared size of the
+// data chunk when it is not negative.
+func writeWav(t *testing.T, dataSize int64, chunks ...any) string {
+	t.Helper()
+	var body []byte
+	body = append(body, "WAVE"...)
+	for i := 0; i < len(chunks); i += 2 {
+		id, payload := chunks[i].(string), chunks[i+1].([]byte)
+		size := uint32(len(payload))
+		if id == "data" && dataSize >= 0 {
+			size = uint32(dataSize)
+		}
+		body = append(body, id...)
+		body = binary.LittleEndian.AppendUint32(body, size)
+		body = append(body, payload...)
+		if len(payload)%2 == 1 {
+			body = append(body, 0)
+		}
+	}
+	file := append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	file = append(file, body...)
+
+	path := filepath.Join(t.TempDir(), "audio.wav")
+	if err := os.WriteFile(path, file, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func pcm(samples ...int16) []byte {
+	var out []byte
+	for _, s := range samples {
+		out = binary.LittleEndian.AppendUint16(out, uint16(s))
+	}
+	return out
+}
+
+func TestReadWavSamples(t *testing.T) {
+	format := make([]byte, 16)
+	tests := []struct {
+		name     string
+		dataSize int64
+		chunks   []any
+		want     []float32
+		wantErr  bool
+	}{
+		{
+			name:     "plain header",
+			dataSize: -1,
+			chunks:   []any{"fmt ", format, "data", pcm(16384, -16384)},
+			want:     []float32{0.5, -0.5},
+		},
+		{
+			name:     "chunks before data",
+			dataSize: -1,
+			chunks:   []any{"fmt ", format, "LIST", []byte("odd"), "data", pcm(16384)},
+			want:     []float32{0.5},
+		},
+		{
+			name:     "chunk after data",
+			dataSize: -1,
+			chunks:   []any{"fmt ", format, "data", pcm(16384), "LIST", []byte("tags")},
+			want:     []float32{0.5},
+		},
+		{
+			name:     "unfinalised size",
+			dataSize: 0xFFFFFFFF,
+			chunks:   []any{"fmt ", format, "data", pcm(16384, 0)},
+			want:     []float32{0.5, 0},
+		},
+		{
+			name:     "no data chunk",
+			dataSize: -1,
+			chunks:   []any{"fmt ", format},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeWav(t, tt.dataSize, tt.chunks...)
+			got, err := readWavSamples(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestWavDuration(t *testing.T) {
+	second := make([]int16, 16000)
+	path := writeWav(t, -1, "fmt ", make([]byte, 16), "LIST", make([]byte, 34), "data", pcm(second...))
+	if got := wavDuration(path); got != 1 {
+		t.Errorf("duration = %v, want 1", got)
+	}
+
+	notWav := filepath.Join(t.TempDir(), "audio.wav")
+	if err := os.WriteFile(notWav, make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := wavDuration(notWav); got != 0 {
+		t.Errorf("non-WAV duration = %v, want 0", got)
+	}
+}
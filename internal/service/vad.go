@@ -0,0 +1,142 @@
+package service
+
+import (
+	"math"
+	"sort"
+
+	"whisper-transcriber/pkg/models"
+)
+
+const (
+	vadFrameMs           = 30
+	vadMinSpeechMs       = 250
+	vadDefaultMinSilence = 500
+	vadDefaultPadMs      = 200
+	vadSilenceFloorDB    = -60.0
+
+	// trimThreshold is -50 dBFS; trimPad keeps the edges of the first and
+	// last words.
+	trimThreshold = 0.00316
+	trimPad       = 0.1
+)
+
+// detectSpeech runs an energy-based voice activity detector over 16 kHz mono
+// samples and returns the speech regions in seconds. The threshold adapts to
+// the recording's noise floor; higher sensitivity keeps quieter speech.
+func detectSpeech(samples []float32, sampleRate int, cfg models.VADConfig) []models.TimeRange {
+	frameLen := sampleRate * vadFrameMs / 1000
+	if frameLen == 0 || len(samples) < frameLen {
+		return nil
+	}
+
+	nFrames := len(samples) / frameLen
+	energies := make([]float64, nFrames)
+	for i := range energies {
+		var sum float64
+		for _, s := range samples[i*frameLen : (i+1)*frameLen] {
+			sum += float64(s) * float64(s)
+		}
+		energies[i] = 10 * math.Log10(sum/float64(frameLen)+1e-12)
+	}
+
+	sensitivity := cfg.Sensitivity
+	if sensitivity <= 0 || sensitivity > 1 {
+		sensitivity = 0.5
+	}
+	// Margin above the noise floor: 18 dB at the least sensitive setting,
+	// 4 dB at the most sensitive.
+	threshold := noiseFloor(energies) + 18 - 14*sensitivity
+	if threshold < vadSilenceFloorDB {
+		threshold = vadSilenceFloorDB
+	}
+
+	minSilence := cfg.MinSilenceMs
+	if minSilence <= 0 {
+		minSilence = vadDefaultMinSilence
+	}
+	pad := cfg.SpeechPadMs
+	if pad <= 0 {
+		pad = vadDefaultPadMs
+	}
+
+	frameSec := float64(vadFrameMs) / 1000
+	total := float64(len(samples)) / float64(sampleRate)
+	padSec := float64(pad) / 1000
+
+	var regions []models.TimeRange
+	start := -1
+	flush := func(end int) {
+		r := models.TimeRange{
+			Start: math.Max(0, float64(start)*frameSec-padSec),
+			End:   math.Min(total, float64(end)*frameSec+padSec),
+		}
+		if n := len(regions); n > 0 && r.Start-regions[n-1].End < float64(minSilence)/1000 {
+			regions[n-1].End = r.End
+		} else {
+			regions = append(regions, r)
+		}
+	}
+	for i, e := range energies {
+		switch {
+		case e >= threshold && start < 0:
+			start = i
+		case e < threshold && start >= 0:
+			flush(i)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		flush(nFrames)
+	}
+
+	kept := regions[:0]
+	for _, r := range regions {
+		if r.End-r.Start-2*padSec >= float64(vadMinSpeechMs)/1000 {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+func noiseFloor(energies []float64) float64 {
+	sorted := append([]float64(nil), energies...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/10]
+}
+
+func speechSamples(samples []float32, sampleRate int, regions []models.TimeRange) []float32 {
+	var out []float32
+	for _, r := range regions {
+		from := int(r.Start * float64(sampleRate))
+		to := int(r.End * float64(sampleRate))
+		if to > len(samples) {
+			to = len(samples)
+		}
+		if from < to {
+			out = append(out, samples[from:to]...)
+		}
+	}
+	return out
+}
+
+// trimmedRange returns the span between the first and last samples above
+// trimThreshold, padded by trimPad, or false if the audio is silent.
+func trimmedRange(samples []float32, sampleRate int) (models.TimeRange, bool) {
+	first, last := -1, -1
+	for i, s := range samples {
+		if math.Abs(float64(s)) > trimThreshold {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return models.TimeRange{}, false
+	}
+	rate := float64(sampleRate)
+	return models.TimeRange{
+		Start: max(0, float64(first)/rate-trimPad),
+		End:   min(float64(len(samples))/rate, float64(last+1)/rate+trimPad),
+	}, true
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+const testRate = 16000
+
+// testSignal returns samples of faint alternating noise with a 440 Hz tone
+// of the given amplitude over each [start, end) span in seconds.
+func testSignal(total float64, amplitude float64, spans ...[2]float64) []float32 {
+	samples := make([]float32, int(total*testRate))
+	for i := range samples {
+		if i%2 == 0 {
+			samples[i] = 0.001
+		} else {
+			samples[i] = -0.001
+		}
+	}
+	for _, span := range spans {
+		for i := int(span[0] * testRate); i < int(span[1]*testRate); i++ {
+			samples[i] = float32(amplitude * math.Sin(2*math.Pi*440*float64(i)/testRate))
+		}
+	}
+	return samples
+}
+
+func TestDetectSpeech(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []float32
+		cfg     models.VADConfig
+		want    []models.TimeRange
+	}{
+		{
+			name:    "one region padded",
+			samples: testSignal(4, 0.3, [2]float64{1, 2}),
+			want:    []models.TimeRange{{Start: 0.8, End: 2.2}},
+		},
+		{
+			name:    "short gap merged",
+			samples: testSignal(4, 0.3, [2]float64{1, 1.5}, [2]float64{1.8, 2.4}),
+			want:    []models.TimeRange{{Start: 0.8, End: 2.6}},
+		},
+		{
+			name:    "long gap kept apart",
+			samples: testSignal(5, 0.3, [2]float64{0.5, 1.5}, [2]float64{3, 4}),
+			want:    []models.TimeRange{{Start: 0.3, End: 1.7}, {Start: 2.8, End: 4.2}},
+		},
+		{
+			name:    "too short to be speech",
+			samples: testSignal(4, 0.3, [2]float64{1, 2}, [2]float64{3.5, 3.6}),
+			want:    []models.TimeRange{{Start: 0.8, End: 2.2}},
+		},
+		{
+			name:    "padding clamped to the audio",
+			samples: testSignal(2, 0.3, [2]float64{0.1, 0.5}, [2]float64{1.2, 2}),
+			want:    []models.TimeRange{{Start: 0, End: 2}},
+		},
+		{
+			name:    "custom silence and padding",
+			samples: testSignal(4, 0.3, [2]float64{1, 1.5}, [2]float64{1.8, 2.4}),
+			cfg:     models.VADConfig{MinSilenceMs: 100, SpeechPadMs: 50},
+			want:    []models.TimeRange{{Start: 0.95, End: 1.55}, {Start: 1.75, End: 2.45}},
+		},
+		{
+			name:    "quiet speech missed at low sensitivity",
+			samples: testSignal(4, 0.005, [2]float64{1, 2}),
+			cfg:     models.VADConfig{Sensitivity: 0.1},
+		},
+		{
+			name:    "quiet speech found at high sensitivity",
+			samples: testSignal(4, 0.005, [2]float64{1, 2}),
+			cfg:     models.VADConfig{Sensitivity: 1},
+			want:    []models.TimeRange{{Start: 0.8, End: 2.2}},
+		},
+		{name: "digital silence", samples: make([]float32, 2*testRate)},
+		{name: "shorter than a frame", samples: make([]float32, 100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectSpeech(tt.samples, testRate, tt.cfg)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			// Regions snap to 30 ms frames.
+			for i, r := range got {
+				if math.Abs(r.Start-tt.want[i].Start) > 0.03 || math.Abs(r.End-tt.want[i].End) > 0.03 {
+					t.Errorf("region %d: got %v, want %v", i, r, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSpeechSamples(t *testing.T) {
+	samples := []float32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	tests := []struct {
+		name    string
+		regions []models.TimeRange
+		want    []float32
+	}{
+		{name: "none"},
+		{name: "concatenated", regions: []models.TimeRange{{Start: 0.1, End: 0.3}, {Start: 0.6, End: 0.8}}, want: []float32{1, 2, 6, 7}},
+		{name: "clamped to the end", regions: []models.TimeRange{{Start: 0.8, End: 2}}, want: []float32{8, 9}},
+		{name: "past the end", regions: []models.TimeRange{{Start: 1.5, End: 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := speechSamples(samples, 10, tt.regions); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimmedRange(t *testing.T) {
+	signal := func(n int, loud ...int) []float32 {
+		samples := make([]float32, n)
+		for _, i := range loud {
+			samples[i] = 0.5
+		}
+		return samples
+	}
+	tests := []struct {
+		name    string
+		samples []float32
+		want    models.TimeRange
+		wantOK  bool
+	}{
+		{name: "silent", samples: signal(100)},
+		{name: "below threshold", samples: []float32{0.001, -0.003, 0.002}},
+		{name: "padded on both sides", samples: signal(100, 30, 60), want: models.TimeRange{Start: 0.2, End: 0.71}, wantOK: true},
+		{name: "pad clamped to the audio", samples: signal(100, 5, 95), want: models.TimeRange{Start: 0, End: 1}, wantOK: true},
+		{name: "negative samples count", samples: []float32{0, 0, -0.5, 0}, want: models.TimeRange{Start: 0, End: 0.04}, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := trimmedRange(tt.samples, 100)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if math.Abs(got.Start-tt.want.Start) > 1e-9 || math.Abs(got.End-tt.want.End) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"whisper-transcriber/pkg/models"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	defaultStableSec = 5
+	defaultPollSec   = 10
+	stableCheckEvery = time.Second
+)
+
+// Watcher reports media files that appear in watched folders once they have
+// finished being written. Folders are rescanned periodically; file system
+// notifications, where available, pick up new files sooner.
+type Watcher struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewWatcher() *Watcher {
+	return &Watcher{}
+}
+
+// Start replaces any running watch. Files already present in the folders are
+// reported too; onFiles and onError are called from the watch goroutine.
+func (w *Watcher) Start(ctx context.Context, cfg models.WatchConfig, onFiles func([]string), onError func(error)) error {
+	if len(cfg.Dirs) == 0 {
+		return fmt.Errorf("no folders to watch")
+	}
+	// Sources whose output already exists were handled before a restart.
+	scan := cfg.Scan
+	if scan.SkipTranscribed == "" {
+		scan.SkipTranscribed = cfg.Transcription.OutputFormat
+	}
+	if scan.OutputDir == "" {
+		scan.OutputDir = cfg.Transcription.OutputDir
+	}
+	var archive string
+	if cfg.ArchiveDir != "" {
+		var err error
+		if archive, err = filepath.Abs(cfg.ArchiveDir); err != nil {
+			return err
+		}
+	}
+	roots := make([]*scanFilter, 0, len(cfg.Dirs))
+	for _, dir := range cfg.Dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("not a folder: %s", dir)
+		}
+		// Archived sources would be detected again as new files.
+		if archive == abs || (scan.Recursive && strings.HasPrefix(archive, abs+string(filepath.Separator))) {
+			return fmt.Errorf("archive folder is inside the watched folder %s", dir)
+		}
+		roots = append(roots, newScanFilter(abs, scan))
+	}
+	// Longest root first so nested watch folders use their own options.
+	sort.Slice(roots, func(i, j int) bool { return len(roots[i].root) > len(roots[j].root) })
+
+	w.Stop()
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	run := &watchRun{
+		roots:   roots,
+		stable:  seconds(cfg.StableSec, defaultStableSec),
+		poll:    seconds(cfg.PollSec, defaultPollSec),
+		pending: make(map[string]fileState),
+		seen:    make(map[string]bool),
+		onFiles: onFiles,
+		onError: onError,
+	}
+	done := make(chan struct{})
+
+	w.mu.Lock()
+	w.cancel = cancel
+	w.done = done
+	w.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		run.loop(watchCtx)
+	}()
+	return nil
+}
+
+// Stop ends the watch and waits for the watch goroutine to exit.
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (w *Watcher) Active() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cancel != nil
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+	since   time.Time
+}
+
+type watchRun struct {
+	roots   []*scanFilter
+	stable  time.Duration
+	poll    time.Duration
+	notify  *fsnotify.Watcher
+	pending map[string]fileState
+	seen    map[string]bool
+	onFiles func([]string)
+	onError func(error)
+}
+
+func (r *watchRun) loop(ctx context.Context) {
+	r.notify = r.startNotify()
+	if r.notify != nil {
+		defer r.notify.Close()
+	}
+	r.rescan(ctx)
+
+	stableTicker := time.NewTicker(stableCheckEvery)
+	defer stableTicker.Stop()
+
+	// Notifications can be dropped or missing on network shares, so the
+	// rescan always runs and notifications only make pickup faster.
+	pollTicker := time.NewTicker(r.poll)
+	defer pollTicker.Stop()
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if r.notify != nil {
+		events, errs = r.notify.Events, r.notify.Errors
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-pollTicker.C:
+			r.rescan(ctx)
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			r.handleEvent(ctx, ev)
+		case err, ok := <-errs:
+			if !ok {
+				return
+			}
+			r.onError(err)
+		case now := <-stableTicker.C:
+			if ready := r.checkStable(now); len(ready) > 0 {
+				r.onFiles(ready)
+			}
+		}
+	}
+}
+
+// startNotify returns nil when notifications cannot cover every folder, in
+// which case the watch relies on rescans alone.
+func (r *watchRun) startNotify() *fsnotify.Watcher {
+	notify, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil
+	}
+	for _, root := range r.roots {
+		if err := addWatchDirs(notify, root, root.root); err != nil {
+			notify.Close()
+			return nil
+		}
+	}
+	return notify
+}
+
+// addWatchDirs watches dir and, for recursive watches, the subfolders the
+// scan options do not exclude.
+func addWatchDirs(notify *fsnotify.Watcher, root *scanFilter, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && root.skipDir(path) {
+			return fs.SkipDir
+		}
+		return notify.Add(path)
+	})
+}
+
+func (r *watchRun) handleEvent(ctx context.Context, ev fsnotify.Event) {
+	root := r.rootFor(ev.Name)
+	if root == nil {
+		return
+	}
+
+	if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+		delete(r.pending, ev.Name)
+		delete(r.seen, ev.Name)
+		return
+	}
+	if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Write) {
+		return
+	}
+
+	info, err := os.Stat(ev.Name)
+	if err != nil {
+		return
+	}
+	if !info.IsDir() {
+		if root.acceptNested(ev.Name) {
+			r.track(ev.Name, info)
+		}
+		return
+	}
+	if ev.Has(fsnotify.Create) && !root.skipNested(ev.Name) {
+		if err := addWatchDirs(r.notify, root, ev.Name); err != nil {
+			r.onError(err)
+		}
+		// Files may have landed before the folder was watched.
+		r.scanDir(ctx, root, ev.Name)
+	}
+}
+
+func (r *watchRun) rescan(ctx context.Context) {
+	// Forget files that are gone so a new file with the same name is picked up.
+	for path := range r.seen {
+		if _, err := os.Stat(path); err != nil {
+			delete(r.seen, path)
+		}
+	}
+	for _, root := range r.roots {
+		r.scanDir(ctx, root, root.root)
+	}
+}
+
+func (r *watchRun) scanDir(ctx context.Context, root *scanFilter, dir string) {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			if path != dir && root.skipDir(path) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if root.acceptFile(path) {
+			if info, err := d.Info(); err == nil {
+				r.track(path, info)
+			}
+		}
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		r.onError(err)
+	}
+}
+
+func (r *watchRun) rootFor(path string) *scanFilter {
+	for _, root := range r.roots {
+		if path == root.root || strings.HasPrefix(path, root.root+string(filepath.Separator)) {
+			return root
+		}
+	}
+	return nil
+}
+
+func (r *watchRun) track(path string, info fs.FileInfo) {
+	if r.seen[path] {
+		return
+	}
+	state, ok := r.pending[path]
+	if ok && state.size == info.Size() && state.modTime.Equal(info.ModTime()) {
+		return
+	}
+	r.pending[path] = fileState{size: info.Size(), modTime: info.ModTime(), since: time.Now()}
+}
+
+// checkStable returns the pending files whose size and modification time
+// have not changed for the stable interval.
+func (r *watchRun) checkStable(now time.Time) []string {
+	var ready []string
+	for path, state := range r.pending {
+		info, err := os.Stat(path)
+		if err != nil {
+			delete(r.pending, path)
+			continue
+		}
+		if info.Size() != state.size || !info.ModTime().Equal(state.modTime) {
+			r.pending[path] = fileState{size: info.Size(), modTime: info.ModTime(), since: now}
+			continue
+		}
+		if info.Size() == 0 || now.Sub(state.since) < r.stable {
+			continue
+		}
+		delete(r.pending, path)
+		r.seen[path] = true
+		ready = append(ready, path)
+	}
+	sort.Strings(ready)
+	return ready
+}
+
+func seconds(v, def int) time.Duration {
+	if v <= 0 {
+		v = def
+	}
+	return time.Duration(v) * time.Second
+}
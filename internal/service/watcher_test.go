@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestWatcherRejectsArchiveInWatchedFolder(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "in"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		archive   string
+		recursive bool
+		wantErr   bool
+	}{
+		{name: "same folder", archive: "in", wantErr: true},
+		{name: "subfolder of a recursive watch", archive: "in/done", recursive: true, wantErr: true},
+		{name: "subfolder of a flat watch", archive: "in/done"},
+		{name: "sibling with a common prefix", archive: "in-done", recursive: true},
+		{name: "no archive", recursive: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := models.WatchConfig{
+				Dirs:    []string{filepath.Join(root, "in")},
+				Scan:    models.ScanOptions{Recursive: tt.recursive},
+				PollSec: 3600,
+			}
+			if tt.archive != "" {
+				cfg.ArchiveDir = filepath.Join(root, filepath.FromSlash(tt.archive))
+			}
+			w := NewWatcher()
+			defer w.Stop()
+			err := w.Start(context.Background(), cfg, func([]string) {}, func(error) {})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
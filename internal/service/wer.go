@@ -0,0 +1,218 @@
+package service
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"whisper-transcriber/pkg/models"
+)
+
+var thousandsSep = regexp.MustCompile(`(\d)[,\x{00A0} ](\d{3})\b`)
+
+// normalizeForEval prepares text for WER/CER scoring and returns its words.
+func normalizeForEval(text string, opts models.TextNormalization) []string {
+	if opts.Numbers {
+		for thousandsSep.MatchString(text) {
+			text = thousandsSep.ReplaceAllString(text, "$1$2")
+		}
+	}
+	if opts.Lowercase {
+		text = strings.ToLower(text)
+	}
+	if opts.StripPunctuation {
+		text = stripPunctuation(text)
+	}
+	words := strings.Fields(text)
+	if opts.Numbers {
+		words = numberWordsToDigits(words)
+	}
+	return words
+}
+
+// stripPunctuation drops apostrophes and replaces other punctuation and
+// symbols with spaces. Decimal points and commas between digits are kept.
+func stripPunctuation(text string) string {
+	runes := []rune(text)
+	var sb strings.Builder
+	for i, r := range runes {
+		if !unicode.IsPunct(r) && !unicode.IsSymbol(r) {
+			sb.WriteRune(r)
+			continue
+		}
+		switch {
+		case r == '\'' || r == '’':
+		case (r == '.' || r == ',') && i > 0 && i+1 < len(runes) &&
+			unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]):
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(' ')
+		}
+	}
+	return sb.String()
+}
+
+var (
+	smallNumbers = map[string]int{
+		"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+		"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+		"eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15,
+		"sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19,
+		"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50,
+		"sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+	}
+	numberScales = map[string]int{"thousand": 1000, "million": 1000000, "billion": 1000000000}
+)
+
+// numberWordsToDigits rewrites runs of English number words, such as
+// "two hundred and five", as digits.
+func numberWordsToDigits(words []string) []string {
+	out := make([]string, 0, len(words))
+	for i := 0; i < len(words); {
+		n, used := parseNumberWords(words[i:])
+		if used == 0 {
+			out = append(out, words[i])
+			i++
+			continue
+		}
+		out = append(out, strconv.Itoa(n))
+		i += used
+	}
+	return out
+}
+
+// Kinds of number word, used to tell where one number ends and the next
+// begins in runs such as "one two three".
+const (
+	numNone = iota
+	numUnit
+	numTeen
+	numTens
+	numScale
+	numAnd
+)
+
+// parseNumberWords reads one number from the start of words. Tens combine
+// only with a following unit ("twenty one"); a unit or teen after a unit or
+// teen, or a tens word after anything but a scale, starts a new number.
+func parseNumberWords(words []string) (value, used int) {
+	total, current := 0, 0
+	prev := numNone
+	for i, word := range words {
+		w := strings.ToLower(word)
+		parts := strings.Split(w, "-")
+		switch {
+		case len(parts) == 2 && smallNumbers[parts[0]] >= 20 && isUnit(parts[1]):
+			if prev != numNone && prev != numScale && prev != numAnd {
+				return total + current, used
+			}
+			current += smallNumbers[parts[0]] + smallNumbers[parts[1]]
+			prev = numUnit
+		case isSmallNumber(w):
+			v := smallNumbers[w]
+			kind := numUnit
+			switch {
+			case v >= 20:
+				kind = numTens
+			case v >= 10:
+				kind = numTeen
+			}
+			switch prev {
+			case numNone, numScale, numAnd:
+			case numTens:
+				if kind != numUnit {
+					return total + current, used
+				}
+			default:
+				return total + current, used
+			}
+			current += v
+			prev = kind
+		case w == "hundred" && current < 100 && (prev == numUnit || prev == numTeen || prev == numTens):
+			current *= 100
+			prev = numScale
+		case numberScales[w] > 0 && prev != numNone && prev != numAnd && (current > 0 || prev != numScale):
+			total += current * numberScales[w]
+			current = 0
+			prev = numScale
+		case w == "and" && prev == numScale && i+1 < len(words) && isSmallNumber(strings.ToLower(words[i+1])):
+			prev = numAnd
+			continue
+		default:
+			return total + current, used
+		}
+		used = i + 1
+	}
+	return total + current, used
+}
+
+func isUnit(w string) bool {
+	v, ok := smallNumbers[w]
+	return ok && v < 10
+}
+
+func isSmallNumber(w string) bool {
+	_, ok := smallNumbers[w]
+	return ok
+}
+
+// editCounts aligns hyp against ref and counts substitutions, deletions and
+// insertions on a minimum-cost path. It keeps two rows, so memory is linear
+// while time is proportional to len(ref)*len(hyp).
+func editCounts[T comparable](ref, hyp []T) (sub, del, ins int) {
+	type cell struct{ cost, sub, del, ins int }
+	prev := make([]cell, len(hyp)+1)
+	cur := make([]cell, len(hyp)+1)
+	for j := range prev {
+		prev[j] = cell{cost: j, ins: j}
+	}
+	for i := 1; i <= len(ref); i++ {
+		cur[0] = cell{cost: i, del: i}
+		for j := 1; j <= len(hyp); j++ {
+			best := prev[j-1]
+			if ref[i-1] != hyp[j-1] {
+				best.cost++
+				best.sub++
+			}
+			if d := prev[j]; d.cost+1 < best.cost {
+				best = d
+				best.cost++
+				best.del++
+			}
+			if n := cur[j-1]; n.cost+1 < best.cost {
+				best = n
+				best.cost++
+				best.ins++
+			}
+			cur[j] = best
+		}
+		prev, cur = cur, prev
+	}
+	last := prev[len(hyp)]
+	return last.sub, last.del, last.ins
+}
+
+// scoreText fills the WER and CER fields of res from the reference and
+// hypothesis text.
+func scoreText(res *models.EvalFileResult, reference, hypothesis string, opts models.TextNormalization) {
+	refWords := normalizeForEval(reference, opts)
+	hypWords := normalizeForEval(hypothesis, opts)
+	res.Substitutions, res.Deletions, res.Insertions = editCounts(refWords, hypWords)
+	res.RefWords = len(refWords)
+	res.WER = ratio(res.Substitutions+res.Deletions+res.Insertions, res.RefWords)
+
+	refChars := []rune(strings.Join(refWords, " "))
+	hypChars := []rune(strings.Join(hypWords, " "))
+	s, d, i := editCounts(refChars, hypChars)
+	res.RefChars = len(refChars)
+	res.CharErrors = s + d + i
+	res.CER = ratio(res.CharErrors, res.RefChars)
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"whisper-transcriber/pkg/models"
+)
+
+func TestEditCounts(t *testing.T) {
+	tests := []struct {
+		name          string
+		ref, hyp      string
+		sub, del, ins int
+	}{
+		{name: "identical", ref: "a b c", hyp: "a b c"},
+		{name: "both empty"},
+		{name: "empty hypothesis", ref: "a b c", del: 3},
+		{name: "empty reference", hyp: "a b", ins: 2},
+		{name: "substitution", ref: "a b c", hyp: "a x c", sub: 1},
+		{name: "deletion", ref: "a b c d", hyp: "a c d", del: 1},
+		{name: "insertion", ref: "a b", hyp: "a x b", ins: 1},
+		{name: "mixed", ref: "a b c d e f", hyp: "a z c e f g", sub: 1, del: 1, ins: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, del, ins := editCounts(strings.Fields(tt.ref), strings.Fields(tt.hyp))
+			if sub != tt.sub || del != tt.del || ins != tt.ins {
+				t.Errorf("got (%d, %d, %d), want (%d, %d, %d)", sub, del, ins, tt.sub, tt.del, tt.ins)
+			}
+		})
+	}
+}
+
+func TestNormalizeForEval(t *testing.T) {
+	all := models.TextNormalization{Lowercase: true, StripPunctuation: true, Numbers: true}
+	tests := []struct {
+		name string
+		text string
+		opts models.TextNormalization
+		want []string
+	}{
+		{name: "none", text: "Hello, World!", want: []string{"Hello,", "World!"}},
+		{name: "lowercase and punctuation", text: "Hello, World! It's fine.", opts: all, want: []string{"hello", "world", "its", "fine"}},
+		{name: "decimals kept", text: "Pi is 3.14, roughly.", opts: all, want: []string{"pi", "is", "3.14", "roughly"}},
+		{name: "thousands separators", text: "It cost 1,250,000 dollars", opts: all, want: []string{"it", "cost", "1250000", "dollars"}},
+		{name: "number words", text: "two hundred and five people", opts: all, want: []string{"205", "people"}},
+		{name: "scales", text: "one thousand two hundred", opts: all, want: []string{"1200"}},
+		{name: "hyphenated", text: "twenty-one pilots", opts: all, want: []string{"21", "pilots"}},
+		{name: "lone and", text: "salt and pepper", opts: all, want: []string{"salt", "and", "pepper"}},
+		{name: "tens and units", text: "twenty one", opts: all, want: []string{"21"}},
+		{name: "counting", text: "one two three", opts: all, want: []string{"1", "2", "3"}},
+		{name: "repeated units", text: "five five five", opts: all, want: []string{"5", "5", "5"}},
+		{name: "unit before a hundred", text: "two one hundred", opts: all, want: []string{"2", "100"}},
+		{name: "teens", text: "twelve thirteen", opts: all, want: []string{"12", "13"}},
+		{name: "year", text: "nineteen eighty four", opts: all, want: []string{"19", "84"}},
+		{name: "tens after tens", text: "twenty thirty", opts: all, want: []string{"20", "30"}},
+		{name: "tens after a scale", text: "one hundred twenty three", opts: all, want: []string{"123"}},
+		{name: "hundreds in a row", text: "one hundred two hundred", opts: all, want: []string{"102", "hundred"}},
+		{name: "and between numbers", text: "one and two", opts: all, want: []string{"1", "and", "2"}},
+		{name: "large", text: "two million three hundred thousand and five", opts: all, want: []string{"2300005"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeForEval(tt.text, tt.opts); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreText(t *testing.T) {
+	opts := models.TextNormalization{Lowercase: true, StripPunctuation: true}
+	var res models.EvalFileResult
+	scoreText(&res, "The cat sat.", "the bat sat", opts)
+	if res.RefWords != 3 || res.Substitutions != 1 || res.WER != 1.0/3 {
+		t.Errorf("words: %+v", res)
+	}
+	if res.RefChars != 11 || res.CharErrors != 1 || res.CER != 1.0/11 {
+		t.Errorf("chars: %+v", res)
+	}
+
+	res = models.EvalFileResult{}
+	scoreText(&res, "", "extra words", opts)
+	if res.WER != 0 || res.Insertions != 2 {
+		t.Errorf("empty reference: %+v", res)
+	}
+}
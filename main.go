@@ -2,7 +2,9 @@ package main
 
 import (
 	"embed"
-	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
 
 	"whisper-transcriber/internal/infrastructure"
 	"whisper-transcriber/internal/service"
@@ -17,16 +19,40 @@ import (
 var assets embed.FS
 
 func main() {
+	os.Exit(run())
+}
+
+// run starts the CLI or the GUI and returns the exit code, so deferred
+// cleanup such as closing the log file happens before the process exits.
+func run() int {
+	if code, ok := runCLI(os.Args[1:]); ok {
+		return code
+	}
+
 	appDir := infrastructure.AppDataDir()
+	if logFile, err := infrastructure.SetupLogging(infrastructure.LogDir()); err == nil {
+		defer logFile.Close()
+	}
+	slog.Info("starting", "appDir", appDir)
 
 	transcriber := service.NewTranscriber()
 	modelMgr := service.NewModelManager(appDir)
 	ffmpeg := service.NewFFmpegService(appDir)
 	formatter := service.NewFormatter()
-	queue := service.NewFileQueue()
-	batch := service.NewBatchProcessor(transcriber, ffmpeg, formatter, queue)
+	prober := service.NewProbeService(appDir)
+	queue := service.NewFileQueue(prober, filepath.Join(appDir, "queue.json"))
+	cache := service.NewResultCache(filepath.Join(appDir, "cache"))
+	settings := service.NewSettings(filepath.Join(appDir, "settings.json"))
+	batch := service.NewBatchProcessor(transcriber, ffmpeg, formatter, queue, cache, settings)
+
+	watcher := service.NewWatcher()
+	libraryDir := filepath.Join(appDir, "library")
+	library := service.NewLibrary(libraryDir)
+	editor := service.NewEditor(library, formatter, libraryDir)
+	evaluator := service.NewEvaluator(transcriber, ffmpeg, filepath.Join(appDir, "evaluations"))
+	benchmarker := service.NewBenchmarker(ffmpeg, filepath.Join(appDir, "benchmarks.json"))
 
-	app := NewApp(transcriber, modelMgr, ffmpeg, formatter, queue, batch)
+	app := NewApp(transcriber, modelMgr, ffmpeg, formatter, queue, cache, library, editor, evaluator, benchmarker, batch, watcher)
 
 	err := wails.Run(&options.App{
 		Title:     "Whisper Transcriber",
@@ -51,6 +77,8 @@ func main() {
 	})
 
 	if err != nil {
-		log.Fatal("Error:", err.Error())
+		slog.Error("app exited", "err", err)
+		return 1
 	}
+	return 0
 }
@@ -1,8 +1,88 @@
 package models
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
+
+// ErrorCode is a machine-readable error category the UI can offer a fix
+// for.
+type ErrorCode string
+
+const (
+	CodeUnsupportedMedia ErrorCode = "unsupported_media"
+	CodeNoAudioStream    ErrorCode = "no_audio_stream"
+	CodeOutOfMemory      ErrorCode = "out_of_memory"
+	CodeModelCorrupt     ErrorCode = "model_corrupt"
+	CodeModelMissing     ErrorCode = "model_missing"
+	CodeFFmpegMissing    ErrorCode = "ffmpeg_missing"
+	CodeDiskFull         ErrorCode = "disk_full"
+	CodeCancelled        ErrorCode = "cancelled"
+	CodeNetwork          ErrorCode = "network"
+	CodeUnknown          ErrorCode = "unknown"
+)
+
+// AppError carries a code and a short message for the user. Details holds
+// technical output, such as ffmpeg's stderr, that is too long for the
+// message but useful in bug reports.
+type AppError struct {
+	Code    ErrorCode
+	Message string
+	Details string
+	Err     error
+}
+
+func NewError(code ErrorCode, message string, err error) *AppError {
+	return &AppError{Code: code, Message: message, Err: err}
+}
+
+func (e *AppError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *AppError) Unwrap() error { return e.Err }
 
 var (
-	ErrModelNotLoaded = errors.New("model not loaded")
-	ErrFFmpegNotFound = errors.New("ffmpeg not found: download it via the app or install system-wide")
+	ErrModelNotLoaded  = &AppError{Code: CodeModelMissing, Message: "model not loaded"}
+	ErrFFmpegNotFound  = &AppError{Code: CodeFFmpegMissing, Message: "ffmpeg not found: download it via the app or install system-wide"}
+	ErrFFprobeNotFound = &AppError{Code: CodeFFmpegMissing, Message: "ffprobe not found: download ffmpeg via the app or install system-wide"}
+	ErrNoAudioStream   = &AppError{Code: CodeNoAudioStream, Message: "file has no audio stream"}
 )
+
+// TransientError marks a failure that may succeed if retried, such as a
+// busy device or a dropped network input.
+type TransientError struct {
+	Err error
+}
+
+func (e *TransientError) Error() string { return e.Err.Error() }
+
+func (e *TransientError) Unwrap() error { return e.Err }
+
+// ErrorInfo is how errors are sent to the frontend.
+type ErrorInfo struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	Details string    `json:"details,omitempty"`
+}
+
+// DescribeError splits err into a code, message and details. Cancellations
+// are recognised anywhere in the chain; other errors without an AppError
+// are reported as unknown with their full text as the message.
+func DescribeError(err error) ErrorInfo {
+	if errors.Is(err, context.Canceled) {
+		return ErrorInfo{Code: CodeCancelled, Message: "cancelled"}
+	}
+	var appErr *AppError
+	if !errors.As(err, &appErr) {
+		return ErrorInfo{Code: CodeUnknown, Message: err.Error()}
+	}
+	info := ErrorInfo{Code: appErr.Code, Message: appErr.Message, Details: appErr.Details}
+	if appErr.Err != nil && info.Details == "" {
+		info.Details = appErr.Err.Error()
+	}
+	return info
+}
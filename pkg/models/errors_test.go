@@ -0,0 +1,54 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDescribeError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorInfo
+	}{
+		{
+			name: "app error",
+			err:  ErrNoAudioStream,
+			want: ErrorInfo{Code: CodeNoAudioStream, Message: "file has no audio stream"},
+		},
+		{
+			name: "wrapped app error",
+			err:  fmt.Errorf("track 2: %w", NewError(CodeOutOfMemory, "out of memory", errors.New("alloc failed"))),
+			want: ErrorInfo{Code: CodeOutOfMemory, Message: "out of memory", Details: "alloc failed"},
+		},
+		{
+			name: "details kept over the cause",
+			err:  &AppError{Code: CodeUnsupportedMedia, Message: "unsupported media", Details: "moov atom not found", Err: errors.New("exit status 1")},
+			want: ErrorInfo{Code: CodeUnsupportedMedia, Message: "unsupported media", Details: "moov atom not found"},
+		},
+		{
+			name: "cancelled",
+			err:  context.Canceled,
+			want: ErrorInfo{Code: CodeCancelled, Message: "cancelled"},
+		},
+		{
+			name: "cancellation inside an app error",
+			err:  NewError(CodeUnsupportedMedia, "unsupported media", fmt.Errorf("ffmpeg: %w", context.Canceled)),
+			want: ErrorInfo{Code: CodeCancelled, Message: "cancelled"},
+		},
+		{
+			name: "plain error",
+			err:  fmt.Errorf("write output: %w", errors.New("permission denied")),
+			want: ErrorInfo{Code: CodeUnknown, Message: "write output: permission denied"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DescribeError(tt.err); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,31 +4,81 @@ import "context"
 
 type Transcriber interface {
 	LoadModel(modelPath string) error
+	SetConcurrency(n int) error
 	IsLoaded() bool
-	TranscribeFile(ctx context.Context, fileID, audioPath, language string, onProgress ProgressFunc) (*TranscriptionResult, error)
+	ModelPath() string
+	TranscribeFile(ctx context.Context, fileID, audioPath string, opts TranscribeOptions, onProgress ProgressFunc) (*TranscriptionResult, error)
 	Close()
 }
 
+// Gate blocks while work is paused and returns early if ctx is cancelled.
+type Gate interface {
+	Wait(ctx context.Context) error
+}
+
 type ModelManager interface {
 	ModelPath() string
 	IsModelAvailable() bool
+	InstalledModels() []string
 	DownloadModel(ctx context.Context, onProgress ProgressFunc) error
 }
 
 type FFmpegService interface {
 	IsAvailable() bool
 	Download(ctx context.Context, onProgress ProgressFunc) error
-	ExtractAudio(ctx context.Context, inputPath string) (wavPath string, err error)
+	ExtractAudio(ctx context.Context, inputPath string, opts ExtractOptions, onProgress ProgressFunc) (wavPath string, err error)
+}
+
+type MediaProber interface {
+	IsAvailable() bool
+	Probe(ctx context.Context, path string) (*MediaInfo, error)
 }
 
 type Formatter interface {
 	WriteOutput(result *TranscriptionResult, sourcePath, format string) (outputPath string, err error)
+	Render(result *TranscriptionResult, format string) (string, error)
+}
+
+// ResultCache stores transcription results by a key derived from the file
+// content and the settings that produced them.
+type ResultCache interface {
+	Get(key string) (*TranscriptionResult, bool)
+	Put(key string, result *TranscriptionResult) error
+	Clear() error
+}
+
+// ThroughputStore remembers how fast each model transcribes on this
+// machine, in audio seconds per wall-clock second.
+type ThroughputStore interface {
+	Throughput(model string) (float64, bool)
+	RecordThroughput(model string, audioSec, wallSec float64) error
+}
+
+type TranscriptLibrary interface {
+	Add(result *TranscriptionResult, outputPath string) (LibraryEntry, error)
+	List() []LibraryEntry
+	Search(query string) ([]LibraryHit, error)
+	Entry(id string) (LibraryEntry, error)
+	Get(id string) (*TranscriptionResult, error)
+	Update(id string, result *TranscriptionResult) (LibraryEntry, error)
+	Delete(id string) error
 }
 
 type FileQueue interface {
-	Add(paths []string) []FileItem
+	Add(ctx context.Context, paths []string) []FileItem
+	Hash(ctx context.Context, ids []string, onStatus StatusFunc) []string
 	Remove(id string)
 	Clear()
 	Snapshot() []FileItem
-	UpdateStatus(id, status string, progress int, errMsg string)
+	UpdateStatus(id, status string, progress int, err error)
+	AddOutput(id, outputPath string)
+	Requeue(id string) error
+	Next(skip map[string]bool) (FileItem, bool)
+	Move(id string, index int) error
+	Reorder(ids []string) error
+	SetPriority(id string, priority int) error
+	SetAudioTracks(id string, streamIndexes []int) error
+	SetRanges(id string, ranges []TimeRange) error
+	SetOutput(id, outputDir, format string) error
+	SetSpeed(audioPerSec float64)
 }
@@ -3,34 +3,337 @@ package models
 import (
 	"crypto/rand"
 	"fmt"
+	"time"
 )
 
 type FileItem struct {
-	ID       string `json:"id"`
-	Path     string `json:"path"`
-	Name     string `json:"name"`
-	SizeMB   int    `json:"sizeMb"`
-	Status   string `json:"status"`
-	Progress int    `json:"progress"`
-	Error    string `json:"error"`
+	ID           string      `json:"id"`
+	Path         string      `json:"path"`
+	Name         string      `json:"name"`
+	SizeMB       int         `json:"sizeMb"`
+	Hash         string      `json:"hash"`
+	Media        *MediaInfo  `json:"media,omitempty"`
+	AudioTracks  []int       `json:"audioTracks"`
+	Ranges       []TimeRange `json:"ranges"`
+	EstimatedSec float64     `json:"estimatedSec"`
+	Priority     int         `json:"priority"`
+	Status       string      `json:"status"`
+	Progress     int         `json:"progress"`
+	Error        string      `json:"error"`
+	ErrorCode    ErrorCode   `json:"errorCode,omitempty"`
+	ErrorDetails string      `json:"errorDetails,omitempty"`
+	OutputPaths  []string    `json:"outputPaths"`
+	// OutputDir and OutputFormat override the batch's settings for this
+	// file when set, so a watched file keeps its watch's output.
+	OutputDir    string `json:"outputDir,omitempty"`
+	OutputFormat string `json:"outputFormat,omitempty"`
 }
 
-type TranscriptionConfig struct {
-	Language     string `json:"language"`
-	OutputFormat string `json:"outputFormat"`
+type MediaInfo struct {
+	Duration     float64           `json:"duration"`
+	Container    string            `json:"container"`
+	VideoCodec   string            `json:"videoCodec"`
+	AudioStreams []AudioStream     `json:"audioStreams"`
+	Tags         map[string]string `json:"tags"`
 }
 
-type Segment struct {
-	Index int     `json:"index"`
+type AudioStream struct {
+	Index         int    `json:"index"`
+	Codec         string `json:"codec"`
+	SampleRate    int    `json:"sampleRate"`
+	Channels      int    `json:"channels"`
+	ChannelLayout string `json:"channelLayout"`
+	Language      string `json:"language"`
+	Title         string `json:"title"`
+	Default       bool   `json:"default"`
+}
+
+// DefaultStream lets ffmpeg pick the audio stream.
+const DefaultStream = -1
+
+// TimeRange is a span of the source in seconds. End == 0 means until the end.
+type TimeRange struct {
 	Start float64 `json:"start"`
 	End   float64 `json:"end"`
-	Text  string  `json:"text"`
+}
+
+// ExtractOptions.Duration is the source's probed length, which progress is
+// measured against; zero means unknown.
+type ExtractOptions struct {
+	StreamIndex int         `json:"streamIndex"`
+	Ranges      []TimeRange `json:"ranges"`
+	Filters     []string    `json:"filters"`
+	Threads     int         `json:"threads"`
+	Duration    float64     `json:"duration"`
+}
+
+// PreprocessConfig selects audio filters applied before transcription.
+// A non-empty Preset other than "custom" overrides the individual fields.
+type PreprocessConfig struct {
+	Preset       string `json:"preset"`
+	Normalize    bool   `json:"normalize"`
+	HighPassHz   int    `json:"highPassHz"`
+	LowPassHz    int    `json:"lowPassHz"`
+	Denoise      string `json:"denoise"`
+	DenoiseModel string `json:"denoiseModel"`
+	TrimSilence  bool   `json:"trimSilence"`
+}
+
+// VADConfig controls voice activity detection. Sensitivity ranges from 0 to 1;
+// higher values keep quieter speech. Zero values fall back to defaults.
+type VADConfig struct {
+	Enabled      bool    `json:"enabled"`
+	Sensitivity  float64 `json:"sensitivity"`
+	MinSilenceMs int     `json:"minSilenceMs"`
+	SpeechPadMs  int     `json:"speechPadMs"`
+}
+
+// DiarizationConfig enables speaker labelling. Speakers <= 0 estimates the
+// number of speakers.
+type DiarizationConfig struct {
+	Enabled  bool `json:"enabled"`
+	Speakers int  `json:"speakers"`
+}
+
+// ConcurrencyConfig sets how many files are transcribed at once and the CPU
+// thread budget shared by ffmpeg and whisper. Zero values use defaults.
+type ConcurrencyConfig struct {
+	Workers    int `json:"workers"`
+	MaxThreads int `json:"maxThreads"`
+}
+
+// RetryConfig controls automatic retries of transient extraction failures.
+// Zero values use defaults; a negative MaxRetries disables retrying.
+type RetryConfig struct {
+	MaxRetries  int `json:"maxRetries"`
+	BaseDelayMs int `json:"baseDelayMs"`
+}
+
+// TranscriptionConfig.OutputDir, when set, receives the output files instead
+// of the source file's directory. With UseCache, files whose content and
+// settings match an earlier run reuse its stored result.
+type TranscriptionConfig struct {
+	Language     string            `json:"language"`
+	OutputFormat string            `json:"outputFormat"`
+	OutputDir    string            `json:"outputDir"`
+	UseCache     bool              `json:"useCache"`
+	Preprocess   PreprocessConfig  `json:"preprocess"`
+	VAD          VADConfig         `json:"vad"`
+	Cleanup      string            `json:"cleanup"`
+	Diarization  DiarizationConfig `json:"diarization"`
+	Concurrency  ConcurrencyConfig `json:"concurrency"`
+	Retry        RetryConfig       `json:"retry"`
+}
+
+// TranscribeOptions.Cleanup selects what happens to suspected hallucinations:
+// "off", "flag", "drop" or "redecode".
+type TranscribeOptions struct {
+	Language    string            `json:"language"`
+	VAD         VADConfig         `json:"vad"`
+	Cleanup     string            `json:"cleanup"`
+	Diarization DiarizationConfig `json:"diarization"`
+	TrimSilence bool              `json:"trimSilence"`
+	Threads     int               `json:"threads"`
+	Gate        Gate              `json:"-"`
+}
+
+type Segment struct {
+	Index      int      `json:"index"`
+	Start      float64  `json:"start"`
+	End        float64  `json:"end"`
+	Text       string   `json:"text"`
+	Confidence float64  `json:"confidence,omitempty"`
+	Flags      []string `json:"flags,omitempty"`
+	Speaker    string   `json:"speaker,omitempty"`
+}
+
+// CleanupReport summarises the hallucination pass over a transcript.
+type CleanupReport struct {
+	Action        string `json:"action"`
+	Repetitions   int    `json:"repetitions"`
+	LowConfidence int    `json:"lowConfidence"`
+	KnownPhrases  int    `json:"knownPhrases"`
+	Flagged       int    `json:"flagged"`
+	Dropped       int    `json:"dropped"`
+	Collapsed     int    `json:"collapsed"`
+	Redecoded     int    `json:"redecoded"`
 }
 
 type TranscriptionResult struct {
-	FilePath string    `json:"filePath"`
-	Language string    `json:"language"`
-	Segments []Segment `json:"segments"`
+	FilePath string            `json:"filePath"`
+	Language string            `json:"language"`
+	Track    string            `json:"track,omitempty"`
+	Filters  []string          `json:"filters,omitempty"`
+	Cleanup  *CleanupReport    `json:"cleanup,omitempty"`
+	Speakers map[string]string `json:"speakers,omitempty"`
+	Segments []Segment         `json:"segments"`
+}
+
+// ConvertOptions controls subtitle conversion. OutputPath defaults to the
+// input path with the new format's extension. FromFPS and ToFPS rescale
+// timings between frame rates when both are set; MaxLineChars re-wraps cue
+// text when positive.
+type ConvertOptions struct {
+	Format       string  `json:"format"`
+	OutputPath   string  `json:"outputPath"`
+	OffsetSec    float64 `json:"offsetSec"`
+	FromFPS      float64 `json:"fromFps"`
+	ToFPS        float64 `json:"toFps"`
+	MaxLineChars int     `json:"maxLineChars"`
+}
+
+// TextNormalization controls how reference and hypothesis text are
+// compared. Numbers converts English number words to digits and drops
+// thousands separators.
+type TextNormalization struct {
+	Lowercase        bool `json:"lowercase"`
+	StripPunctuation bool `json:"stripPunctuation"`
+	Numbers          bool `json:"numbers"`
+}
+
+// EvalConfig describes an evaluation run. Every media file in Dir is paired
+// with a reference transcript of the same name (.txt, .srt, .vtt, .ass or
+// .json). Reports go to ReportDir, or the app's evaluations folder.
+type EvalConfig struct {
+	Dir           string              `json:"dir"`
+	Recursive     bool                `json:"recursive"`
+	ReportDir     string              `json:"reportDir"`
+	Normalize     TextNormalization   `json:"normalize"`
+	Transcription TranscriptionConfig `json:"transcription"`
+}
+
+type EvalFileResult struct {
+	Path          string  `json:"path"`
+	Reference     string  `json:"reference"`
+	RefWords      int     `json:"refWords"`
+	Substitutions int     `json:"substitutions"`
+	Deletions     int     `json:"deletions"`
+	Insertions    int     `json:"insertions"`
+	WER           float64 `json:"wer"`
+	RefChars      int     `json:"refChars"`
+	CharErrors    int     `json:"charErrors"`
+	CER           float64 `json:"cer"`
+	AudioSec      float64 `json:"audioSec"`
+	ExtractSec    float64 `json:"extractSec"`
+	TranscribeSec float64 `json:"transcribeSec"`
+	RTF           float64 `json:"rtf"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// EvalReport aggregates an evaluation. WER and CER are corpus-level: total
+// errors over total reference words or characters. RTF is processing time
+// over audio time.
+type EvalReport struct {
+	StartedAt   time.Time           `json:"startedAt"`
+	Model       string              `json:"model"`
+	Config      TranscriptionConfig `json:"config"`
+	Filters     []string            `json:"filters"`
+	Threads     int                 `json:"threads"`
+	Normalize   TextNormalization   `json:"normalize"`
+	Files       []EvalFileResult    `json:"files"`
+	WER         float64             `json:"wer"`
+	CER         float64             `json:"cer"`
+	AudioSec    float64             `json:"audioSec"`
+	ProcessSec  float64             `json:"processSec"`
+	RTF         float64             `json:"rtf"`
+	ReportPaths []string            `json:"reportPaths"`
+}
+
+// BenchmarkConfig selects what to benchmark. Empty Models means every
+// installed model; empty Threads picks a spread up to the CPU count.
+type BenchmarkConfig struct {
+	SamplePath string   `json:"samplePath"`
+	Models     []string `json:"models"`
+	Threads    []int    `json:"threads"`
+	Language   string   `json:"language"`
+}
+
+type MachineInfo struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+	CPUs int    `json:"cpus"`
+}
+
+// BenchmarkResult is one model at one thread count. EncodeSec is measured
+// on a single 30-second window and scaled to the sample; DecodeSec is the
+// rest of the transcription time. PeakMemMB is the memory added by loading
+// and running the model, and is omitted where it cannot be measured.
+type BenchmarkResult struct {
+	Model         string  `json:"model"`
+	ModelPath     string  `json:"modelPath"`
+	ModelSizeMB   int     `json:"modelSizeMb"`
+	Threads       int     `json:"threads"`
+	LoadSec       float64 `json:"loadSec"`
+	EncodeSec     float64 `json:"encodeSec"`
+	DecodeSec     float64 `json:"decodeSec"`
+	TranscribeSec float64 `json:"transcribeSec"`
+	AudioSec      float64 `json:"audioSec"`
+	RTF           float64 `json:"rtf"`
+	PeakMemMB     int     `json:"peakMemMb,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+type BenchmarkReport struct {
+	StartedAt time.Time         `json:"startedAt"`
+	Machine   MachineInfo       `json:"machine"`
+	Sample    string            `json:"sample"`
+	Results   []BenchmarkResult `json:"results"`
+}
+
+type ModelRecommendation struct {
+	Model     string  `json:"model"`
+	ModelPath string  `json:"modelPath"`
+	Threads   int     `json:"threads"`
+	RTF       float64 `json:"rtf"`
+	Reason    string  `json:"reason"`
+}
+
+// LibraryEntry describes a transcript stored in the library.
+type LibraryEntry struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title"`
+	SourcePath string    `json:"sourcePath"`
+	OutputPath string    `json:"outputPath"`
+	Track      string    `json:"track,omitempty"`
+	Language   string    `json:"language"`
+	Duration   float64   `json:"duration"`
+	Segments   int       `json:"segments"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// LibraryHit is a transcript matching a search, with the segments that
+// contain the query terms.
+type LibraryHit struct {
+	Entry    LibraryEntry `json:"entry"`
+	Segments []Segment    `json:"segments"`
+}
+
+// ScanOptions controls folder imports. Extensions defaults to the supported
+// media types; SkipTranscribed names an output format whose existing file,
+// next to the source or in OutputDir, means the source is skipped.
+type ScanOptions struct {
+	Recursive       bool     `json:"recursive"`
+	Include         []string `json:"include"`
+	Exclude         []string `json:"exclude"`
+	Extensions      []string `json:"extensions"`
+	IncludeHidden   bool     `json:"includeHidden"`
+	SkipTranscribed string   `json:"skipTranscribed"`
+	OutputDir       string   `json:"outputDir"`
+}
+
+// WatchConfig configures watch-folder mode. New files are enqueued once their
+// size has not changed for StableSec; PollSec is the rescan interval, which
+// backs up file system notifications. Sources that already have output in the
+// transcription format are skipped. Processed sources are moved to ArchiveDir
+// when it is set.
+type WatchConfig struct {
+	Dirs          []string            `json:"dirs"`
+	Scan          ScanOptions         `json:"scan"`
+	ArchiveDir    string              `json:"archiveDir"`
+	StableSec     int                 `json:"stableSec"`
+	PollSec       int                 `json:"pollSec"`
+	Transcription TranscriptionConfig `json:"transcription"`
 }
 
 type LangOption struct {
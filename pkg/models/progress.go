@@ -2,4 +2,16 @@ package models
 
 type ProgressFunc func(percent int, downloadedMB, totalMB string)
 
-type StatusFunc func(fileID, status string, progress int, errMsg string)
+// StatusFunc reports a file's status; err is set for "error", "retrying" and
+// "duplicate".
+type StatusFunc func(fileID, status string, progress int, err error)
+
+// FileTiming accompanies a file status update. Estimates are zero when
+// unknown; BatchETASec covers every file still to run.
+type FileTiming struct {
+	ElapsedSec  float64 `json:"elapsedSec"`
+	ETASec      float64 `json:"etaSec"`
+	BatchETASec float64 `json:"batchEtaSec"`
+}
+
+type TimedStatusFunc func(fileID, status string, progress int, err error, timing FileTiming)
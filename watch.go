@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"whisper-transcriber/internal/infrastructure"
+	"whisper-transcriber/pkg/models"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// StartWatch enqueues media files as they appear in the configured folders
+// and transcribes them with cfg.Transcription. Each watched file keeps the
+// watch's output folder and format; when it joins a batch that is already
+// running, its other settings, such as the language, are the batch's.
+func (a *App) StartWatch(cfg models.WatchConfig) error {
+	if cfg.ArchiveDir != "" {
+		if err := os.MkdirAll(cfg.ArchiveDir, 0755); err != nil {
+			return fmt.Errorf("archive folder: %w", err)
+		}
+	}
+
+	a.mu.Lock()
+	a.watchConfig = cfg
+	a.mu.Unlock()
+
+	err := a.watcher.Start(a.ctx, cfg, a.enqueueWatched, func(err error) {
+		slog.Warn("watch error", "err", err)
+		emitError(a.ctx, "watch:error", err)
+	})
+	if err != nil {
+		return err
+	}
+	slog.Info("watch started", "dirs", cfg.Dirs)
+	wailsRuntime.EventsEmit(a.ctx, "watch:started", cfg.Dirs)
+	return nil
+}
+
+func (a *App) StopWatch() {
+	a.watcher.Stop()
+	wailsRuntime.EventsEmit(a.ctx, "watch:stopped", nil)
+}
+
+func (a *App) IsWatching() bool {
+	return a.watcher.Active()
+}
+
+// enqueueWatched adds newly settled files, skipping paths the queue already
+// holds, and starts a batch unless one is running.
+func (a *App) enqueueWatched(paths []string) {
+	queued := make(map[string]bool)
+	for _, f := range a.queue.Snapshot() {
+		queued[f.Path] = true
+	}
+	var fresh []string
+	for _, p := range paths {
+		if !queued[p] {
+			fresh = append(fresh, p)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+
+	slog.Info("watched files ready", "count", len(fresh))
+	items := a.queue.Add(a.ctx, fresh)
+	var ids []string
+	a.mu.Lock()
+	config := a.watchConfig.Transcription
+	for _, item := range items {
+		if item.Status != "error" {
+			a.watchSources[item.ID] = item.Path
+			ids = append(ids, item.ID)
+		}
+	}
+	a.mu.Unlock()
+	for _, id := range ids {
+		if err := a.queue.SetOutput(id, config.OutputDir, config.OutputFormat); err != nil {
+			slog.Warn("set watch output failed", "file", id, "err", err)
+		}
+	}
+	wailsRuntime.EventsEmit(a.ctx, "watch:added", items)
+
+	a.hashFiles(ids, func() {
+		if err := a.runOrJoinBatch(config); err != nil {
+			emitError(a.ctx, "watch:error", err)
+		}
+	})
+}
+
+// archiveWatched moves a finished watched source into the archive folder.
+// Items with several tracks complete more than once; only the first moves.
+func (a *App) archiveWatched(fileID string) {
+	a.mu.Lock()
+	path, ok := a.watchSources[fileID]
+	archiveDir := a.watchConfig.ArchiveDir
+	delete(a.watchSources, fileID)
+	a.mu.Unlock()
+	if !ok || archiveDir == "" {
+		return
+	}
+
+	dst := uniquePath(filepath.Join(archiveDir, filepath.Base(path)))
+	if err := infrastructure.MoveFile(path, dst); err != nil {
+		slog.Warn("archive failed", "file", path, "err", err)
+		emitError(a.ctx, "watch:error", fmt.Errorf("archive %s: %w", filepath.Base(path), err))
+		return
+	}
+	wailsRuntime.EventsEmit(a.ctx, "watch:archived", map[string]interface{}{
+		"fileID": fileID,
+		"path":   dst,
+	})
+}
+
+// resumeWatch starts another batch when watched files arrived after the
+// finished batch stopped taking new items.
+func (a *App) resumeWatch() {
+	if !a.watcher.Active() {
+		return
+	}
+	a.mu.Lock()
+	config := a.watchConfig.Transcription
+	sources := make(map[string]bool, len(a.watchSources))
+	for id := range a.watchSources {
+		sources[id] = true
+	}
+	a.mu.Unlock()
+
+	for _, f := range a.queue.Snapshot() {
+		if sources[f.ID] && f.Status == "pending" {
+			if err := a.runOrJoinBatch(config); err != nil {
+				emitError(a.ctx, "watch:error", err)
+			}
+			return
+		}
+	}
+}
+
+func uniquePath(path string) string {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path
+	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}